@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/beardnick/go-test-coverage/internal/config"
+)
+
+// noConfig disables the lookup of a configuration file.
+const noConfig = "none"
+
+// outputFlags select the outputs together: when one of them is given on the
+// command line, none of them is taken from the configuration file, so that
+// e.g. -out is not overridden by a configured output.html.
+var outputFlags = []string{"format", "out", "out-dir"}
+
+// applyConfig sets every flag of flags that was not given on the command
+// line, except the skipped ones, to its value from the configuration file.
+// An empty configPath looks the file up from the working directory.
+func applyConfig(flags *flag.FlagSet, configPath string, skip ...string) error {
+	if configPath == noConfig {
+		return nil
+	}
+	if configPath == "" {
+		found, err := config.Find(".")
+		if err != nil {
+			return err
+		}
+		if found == "" {
+			return nil
+		}
+		configPath = found
+	}
+
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return err
+	}
+
+	explicit := make(map[string]bool)
+	for _, name := range skip {
+		explicit[name] = true
+	}
+	flags.Visit(func(set *flag.Flag) {
+		explicit[set.Name] = true
+	})
+	for _, name := range outputFlags {
+		if explicit[name] {
+			for _, other := range outputFlags {
+				explicit[other] = true
+			}
+			break
+		}
+	}
+
+	for _, setting := range cfg.Settings() {
+		if explicit[setting.Name] || flags.Lookup(setting.Name) == nil {
+			continue
+		}
+		if err := flags.Set(setting.Name, setting.Value); err != nil {
+			return fmt.Errorf("%s: %s: %w", configPath, setting.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// runConfig implements `beautiful-coverage config init`.
+func runConfig(args []string) {
+	if len(args) == 0 || args[0] != "init" {
+		fmt.Fprintln(os.Stderr, "usage: beautiful-coverage config init [-force] [-out path]")
+		os.Exit(2)
+	}
+
+	flags := flag.NewFlagSet("config init", flag.ExitOnError)
+	outputPath := flags.String("out", config.FileNames[0], "configuration file to write")
+	force := flags.Bool("force", false, "overwrite an existing file")
+	flags.Parse(args[1:])
+
+	if !*force {
+		if _, err := os.Stat(*outputPath); err == nil {
+			fmt.Fprintf(os.Stderr, "%s already exists, use -force to overwrite it\n", *outputPath)
+			os.Exit(1)
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	if err := os.WriteFile(*outputPath, []byte(config.DefaultFile), 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Printf("wrote %s\n", *outputPath)
+}
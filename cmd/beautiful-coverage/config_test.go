@@ -0,0 +1,129 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestApplyConfigCommandLineWins(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	content := `profiles:
+  - coverage.out
+title: From Config
+exclude:
+  - "**/mocks/**"
+  - "*.pb.go"
+thresholds:
+  total: 75
+`
+	if err := os.WriteFile(configPath, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	var profiles, excludes stringList
+	flags.Var(&profiles, "profile", "")
+	flags.Var(&excludes, "exclude", "")
+	title := flags.String("title", "Go Coverage Report", "")
+	minTotal := flags.Float64("min-total", 0, "")
+	out := flags.String("out", "", "")
+	if err := flags.Parse([]string{"-title", "From CLI", "-exclude", "vendor/**"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := applyConfig(flags, configPath, "out"); err != nil {
+		t.Fatalf("applyConfig() error = %v", err)
+	}
+
+	if *title != "From CLI" {
+		t.Errorf("title = %q, want the command line value", *title)
+	}
+	if want := (stringList{"vendor/**"}); !reflect.DeepEqual(excludes, want) {
+		t.Errorf("exclude = %v, want the command line list %v", excludes, want)
+	}
+	if want := (stringList{filepath.Join(dir, "coverage.out")}); !reflect.DeepEqual(profiles, want) {
+		t.Errorf("profile = %v, want the configured %v", profiles, want)
+	}
+	if *minTotal != 75 {
+		t.Errorf("min-total = %v, want the configured 75", *minTotal)
+	}
+	if *out != "" {
+		t.Errorf("out = %q, want the skipped flag unset", *out)
+	}
+}
+
+func TestApplyConfigNone(t *testing.T) {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	title := flags.String("title", "default", "")
+	if err := applyConfig(flags, noConfig); err != nil {
+		t.Fatalf("applyConfig() error = %v", err)
+	}
+	if *title != "default" {
+		t.Errorf("title = %q, want default", *title)
+	}
+}
+
+func TestApplyConfigInvalidFile(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte("title: x\nthresholds:\n  file: 101\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.String("title", "", "")
+	err := applyConfig(flags, configPath)
+	want := configPath + ": line 3: thresholds.file: 101 is outside 0-100"
+	if err == nil || err.Error() != want {
+		t.Fatalf("applyConfig() error = %v, want %q", err, want)
+	}
+}
+
+func TestApplyConfigOutputFlags(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	content := `output:
+  html: coverage.html
+  json: coverage.json
+`
+	if err := os.WriteFile(configPath, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		args        []string
+		wantFormats stringList
+	}{
+		{
+			name:        "configured outputs",
+			wantFormats: stringList{"html=" + filepath.Join(dir, "coverage.html"), "json=" + filepath.Join(dir, "coverage.json")},
+		},
+		{name: "out", args: []string{"-out", "report.html"}},
+		{name: "out-dir", args: []string{"-out-dir", "site"}},
+		{name: "format", args: []string{"-format", "text"}, wantFormats: stringList{"text"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			flags := flag.NewFlagSet("test", flag.ContinueOnError)
+			var formats stringList
+			flags.Var(&formats, "format", "")
+			flags.String("out", "", "")
+			flags.String("out-dir", "", "")
+			if err := flags.Parse(test.args); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := applyConfig(flags, configPath); err != nil {
+				t.Fatalf("applyConfig() error = %v", err)
+			}
+			if !reflect.DeepEqual(formats, test.wantFormats) {
+				t.Errorf("format = %v, want %v", formats, test.wantFormats)
+			}
+		})
+	}
+}
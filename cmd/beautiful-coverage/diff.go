@@ -0,0 +1,109 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/beardnick/go-test-coverage/internal/config"
+	"github.com/beardnick/go-test-coverage/internal/render"
+	"github.com/beardnick/go-test-coverage/internal/report"
+)
+
+// runDiff implements `beautiful-coverage diff`, which renders the head
+// report annotated with its coverage changes since the base report.
+func runDiff(args []string) {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	configPath := flags.String("config", "", "configuration file (default: "+config.FileNames[0]+" in the working directory or a parent, "+noConfig+" to disable)")
+	basePath := flags.String("base", "", "coverage file of the base version")
+	headPath := flags.String("head", "", "coverage file of the head version")
+	inputFormat := flags.String("input-format", "auto", "format of -base and -head: auto, go, lcov or cobertura")
+	outputPath := flags.String("out", "coverage-diff.html", "output HTML file")
+	root := flags.String("root", "", "root directory for resolving source files (defaults to head directory)")
+	title := flags.String("title", "Go Coverage Diff", "report title")
+	var pathMappings stringList
+	flags.Var(&pathMappings, "path-map", "rewrite file names starting with from to start with to, as from=to (repeatable)")
+	var includePatterns stringList
+	flags.Var(&includePatterns, "include", "only report files or packages matching this glob, or regex with re: prefix (repeatable)")
+	var excludePatterns stringList
+	flags.Var(&excludePatterns, "exclude", "exclude files or packages matching this glob, or regex with re: prefix (repeatable)")
+	bandSpec := flags.String("bands", "", "coverage bands as name=min[:color] list, e.g. high=80,medium=60,low=0.1,none=0")
+	includeGenerated := flags.Bool("include-generated", false, "keep files with a \"Code generated ... DO NOT EDIT.\" header")
+	flags.Parse(args)
+
+	// The configured output and title belong to the coverage report, not to
+	// the comparison.
+	if err := applyConfig(flags, *configPath, "out", "title"); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if *basePath == "" || *headPath == "" {
+		fmt.Fprintln(os.Stderr, "diff requires -base and -head")
+		os.Exit(2)
+	}
+
+	rootPath := *root
+	if rootPath == "" {
+		rootPath = filepath.Dir(*headPath)
+	}
+
+	format, err := report.ParseInputFormat(*inputFormat)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	bands, err := parseBands(*bandSpec)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	mappings, err := parsePathMappings(pathMappings)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	options := report.Options{
+		Root:             rootPath,
+		Title:            *title,
+		Include:          includePatterns,
+		Exclude:          excludePatterns,
+		IncludeGenerated: *includeGenerated,
+		PathMappings:     mappings,
+		Bands:            bands,
+	}
+
+	baseReport, err := loadReport([]string{*basePath}, nil, format, options)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	headReport, err := loadReport([]string{*headPath}, nil, format, options)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	report.Compare(&baseReport, &headReport, *basePath, *headPath)
+
+	if err := writeFile(*outputPath, func(writer io.Writer) error {
+		return render.HTML(writer, headReport, render.HTMLOptions{})
+	}); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	comparison := headReport.Comparison
+	fmt.Printf("total coverage: %s -> %s (%s)\n", comparison.Total.BasePercent, headReport.TotalCoveragePercent, comparison.Total.PercentText())
+	for _, file := range headReport.Files {
+		if file.Delta == nil || !file.Delta.Dropped() {
+			break
+		}
+		fmt.Printf("  %s: %s -> %s (%s)\n", file.Name, file.Delta.BasePercent, file.CoveragePercent, file.Delta.PercentText())
+	}
+}
@@ -5,43 +5,356 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/beardnick/go-test-coverage/internal/config"
 	"github.com/beardnick/go-test-coverage/internal/render"
 	"github.com/beardnick/go-test-coverage/internal/report"
 )
 
+type stringList []string
+
+func (list *stringList) String() string {
+	return strings.Join(*list, ",")
+}
+
+func (list *stringList) Set(value string) error {
+	*list = append(*list, value)
+	return nil
+}
+
 func main() {
-	profilePath := flag.String("profile", "coverage.out", "path to coverprofile file")
-	outputPath := flag.String("out", "coverage.html", "output HTML file")
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "diff":
+			runDiff(os.Args[2:])
+			return
+		case "config":
+			runConfig(os.Args[2:])
+			return
+		}
+	}
+
+	configPath := flag.String("config", "", "configuration file (default: "+config.FileNames[0]+" in the working directory or a parent, "+noConfig+" to disable)")
+	var profilePatterns stringList
+	flag.Var(&profilePatterns, "profile", "path or glob of a coverage file, - for stdin (repeatable, default coverage.out)")
+	inputFormat := flag.String("input-format", "auto", "format of -profile files: auto, go, lcov or cobertura")
+	var coverDirs stringList
+	flag.Var(&coverDirs, "coverdir", "GOCOVERDIR directory with binary coverage data (repeatable)")
+	var formats stringList
+	flag.Var(&formats, "format", "output format html, json, markdown, cobertura, lcov, badge or text, as format or format=path (repeatable, comma separated, default html)")
+	outputPath := flag.String("out", "", "output file of the first format without a path, - for stdout (default coverage.html, coverage.json, coverage.md, coverage.xml, lcov.info, coverage.svg, stdout for text)")
+	outDir := flag.String("out-dir", "", "write the HTML report as a site with one page per file to this directory, instead of coverage.html")
+	htmlVirtual := flag.Bool("html-virtual", false, "embed the files in the html output as compressed data rendered by the browser on demand, for large reports")
+	jsonLines := flag.Bool("json-lines", false, "include the class and hits of every line in the json output")
+	markdownDepth := flag.Int("markdown-depth", render.DefaultMarkdownOptions.Depth, "deepest directory level listed in the markdown package table")
+	markdownFiles := flag.Int("markdown-files", render.DefaultMarkdownOptions.Files, "number of least covered files listed in the markdown output")
+	markdownMax := flag.Int("markdown-max", render.DefaultMarkdownOptions.MaxLength, "maximum length of the markdown output in characters")
+	badgeLabel := flag.String("badge-label", render.DefaultBadgeLabel, "label on the left of the badge output")
+	var badgePackages stringList
+	flag.Var(&badgePackages, "badge-package", "also write a badge for this report directory, as dir or dir=path (repeatable)")
+	textDepth := flag.Int("text-depth", render.DefaultTextOptions.Depth, "deepest tree level shown by the text output, 0 for all")
+	textSort := flag.String("text-sort", render.DefaultTextOptions.Sort, "order of the text output: name, coverage or uncovered")
+	textUncovered := flag.Bool("text-uncovered", false, "list the uncovered lines of every file in the text output")
+	colorMode := flag.String("color", colorAuto, "color the text output: auto, always or never (auto honors NO_COLOR and colors terminals only)")
 	root := flag.String("root", "", "root directory for resolving source files (defaults to profile directory)")
 	title := flag.String("title", "Go Coverage Report", "report title")
+	includeUntested := flag.Bool("include-untested", false, "add packages without coverage data as 0% files")
+	untestedPattern := flag.String("untested-pattern", report.DefaultUntestedPattern, "package pattern listed by -include-untested")
+	var pathMappings stringList
+	flag.Var(&pathMappings, "path-map", "rewrite file names starting with from to start with to, as from=to (repeatable)")
+	var includePatterns stringList
+	flag.Var(&includePatterns, "include", "only report files or packages matching this glob, or regex with re: prefix (repeatable)")
+	var excludePatterns stringList
+	flag.Var(&excludePatterns, "exclude", "exclude files or packages matching this glob, or regex with re: prefix (repeatable)")
+	bandSpec := flag.String("bands", "", "coverage bands as name=min[:color] list, e.g. high=80,medium=60,low=0.1,none=0")
+	minTotal := flag.Float64("min-total", 0, "minimum total coverage percent, exit 1 when below")
+	minPackage := flag.Float64("min-package", 0, "minimum coverage percent of every package directory, exit 1 when below")
+	minFile := flag.Float64("min-file", 0, "minimum coverage percent of every file, exit 1 when below")
+	patchBase := flag.String("patch-base", "", "git ref to compute patch coverage of the lines changed since its merge base")
+	patchDiff := flag.String("patch-diff", "", "unified diff file to compute patch coverage of, - for stdin")
+	historyPath := flag.String("history", "", "JSON-lines history file to append this run to and draw trends from")
+	commit := flag.String("commit", "", "commit recorded in -history (defaults to git HEAD of -root)")
+	includeGenerated := flag.Bool("include-generated", false, "keep files with a \"Code generated ... DO NOT EDIT.\" header")
 	flag.Parse()
 
-	if *profilePath == "" {
-		fmt.Fprintln(os.Stderr, "-profile cannot be empty")
+	if err := applyConfig(flag.CommandLine, *configPath); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if len(profilePatterns) == 0 && len(coverDirs) == 0 {
+		profilePatterns = stringList{"coverage.out"}
+	}
+
+	profilePaths, err := expandProfiles(profilePatterns)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(2)
 	}
 
 	rootPath := *root
 	if rootPath == "" {
-		rootPath = filepath.Dir(*profilePath)
+		rootPath = "."
+		if len(profilePaths) > 0 {
+			rootPath = filepath.Dir(profilePaths[0])
+		}
+	}
+
+	if *patchBase != "" && *patchDiff != "" {
+		fmt.Fprintln(os.Stderr, "-patch-base and -patch-diff cannot be combined")
+		os.Exit(2)
 	}
 
-	reportData, err := report.Generate(*profilePath, rootPath, *title)
+	// A site replaces the default HTML file; other outputs are only written
+	// when asked for.
+	outputs := make([]output, 0)
+	if *outDir == "" || len(formats) > 0 || *outputPath != "" {
+		outputs, err = parseOutputs(formats, *outputPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	}
+
+	sortOrder, err := render.ParseSort(*textSort)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "invalid -text-sort:", err)
+		os.Exit(2)
+	}
+	if *colorMode != colorAuto && *colorMode != colorAlways && *colorMode != colorNever {
+		fmt.Fprintf(os.Stderr, "invalid -color %q: expected %s, %s or %s\n", *colorMode, colorAuto, colorAlways, colorNever)
+		os.Exit(2)
+	}
+
+	format, err := report.ParseInputFormat(*inputFormat)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		os.Exit(2)
 	}
 
-	outputFile, err := os.Create(*outputPath)
+	bands, err := parseBands(*bandSpec)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		os.Exit(2)
 	}
-	defer outputFile.Close()
 
-	if err := render.HTML(outputFile, reportData); err != nil {
+	mappings, err := parsePathMappings(pathMappings)
+	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	options := report.Options{
+		Root:             rootPath,
+		Title:            *title,
+		IncludeUntested:  *includeUntested,
+		UntestedPattern:  *untestedPattern,
+		Include:          includePatterns,
+		Exclude:          excludePatterns,
+		IncludeGenerated: *includeGenerated,
+		PathMappings:     mappings,
+		Bands:            bands,
+	}
+
+	reportData, err := loadReport(profilePaths, coverDirs, format, options)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if *patchBase != "" || *patchDiff != "" {
+		if err := applyPatch(&reportData, rootPath, *patchBase, *patchDiff); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		patch := reportData.Patch
+		fmt.Fprintf(os.Stderr, "patch coverage: %s (%d/%d changed lines)\n", patch.CoveragePercent, patch.CoveredLines, patch.TotalLines)
+		for _, file := range patch.Files {
+			if file.UncoveredLines != "" {
+				fmt.Fprintf(os.Stderr, "  %s: uncovered lines %s\n", file.Name, file.UncoveredLines)
+			}
+		}
+	}
+
+	if *historyPath != "" {
+		if err := updateHistory(&reportData, *historyPath, rootPath, *commit); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	thresholds := report.Thresholds{Total: *minTotal, Package: *minPackage, File: *minFile}
+	violations := report.CheckThresholds(&reportData, thresholds)
+
+	renderOpts := renderOptions{
+		html: render.HTMLOptions{Virtual: *htmlVirtual},
+		json: render.JSONOptions{Lines: *jsonLines},
+		markdown: render.MarkdownOptions{
+			Depth:     *markdownDepth,
+			Files:     *markdownFiles,
+			MaxLength: *markdownMax,
+		},
+		badge: badgeOptions{label: *badgeLabel, packages: badgePackages},
+		text: render.TextOptions{
+			Depth:     *textDepth,
+			Sort:      sortOrder,
+			Uncovered: *textUncovered,
+			BarWidth:  render.DefaultTextOptions.BarWidth,
+		},
+		color: *colorMode,
+	}
+	for _, target := range outputs {
+		if err := writeOutput(target, reportData, renderOpts); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	if *outDir != "" {
+		if err := render.Site(*outDir, reportData); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	if len(violations) > 0 {
+		fmt.Fprintf(os.Stderr, "coverage below threshold (%d):\n", len(violations))
+		for _, violation := range violations {
+			fmt.Fprintln(os.Stderr, "  "+violation.String())
+		}
 		os.Exit(1)
 	}
 }
+
+func applyPatch(reportData *report.Report, rootPath, base, diffPath string) error {
+	if diffPath != "" {
+		changes, err := report.ParseDiff(diffPath)
+		if err != nil {
+			return err
+		}
+		report.ApplyPatch(reportData, changes, diffPath)
+		return nil
+	}
+
+	changes, err := report.GitChangedLines(rootPath, base)
+	if err != nil {
+		return err
+	}
+	report.ApplyPatch(reportData, changes, base)
+	return nil
+}
+
+func updateHistory(reportData *report.Report, historyPath, rootPath, commit string) error {
+	records, err := report.ReadHistory(historyPath)
+	if err != nil {
+		return err
+	}
+
+	if commit == "" {
+		commit = report.GitCommit(rootPath)
+	}
+	record := report.NewHistoryRecord(*reportData, commit, time.Now())
+	if err := report.AppendHistory(historyPath, record); err != nil {
+		return err
+	}
+
+	report.ApplyHistory(reportData, append(records, record))
+	return nil
+}
+
+func loadReport(profilePaths, coverDirs []string, format report.InputFormat, options report.Options) (report.Report, error) {
+	inputs, err := report.ReadInputs(profilePaths, format)
+	if err != nil {
+		return report.Report{}, err
+	}
+
+	var coverData *report.CoverDirSummary
+	if len(coverDirs) > 0 {
+		dirProfiles, summary, err := report.ParseCoverDirs(coverDirs)
+		if err != nil {
+			return report.Report{}, err
+		}
+		inputs.Profiles, err = report.MergeProfiles(inputs.Profiles, dirProfiles)
+		if err != nil {
+			return report.Report{}, err
+		}
+		coverData = &summary
+	}
+
+	formats := inputs.Formats()
+	if len(formats) > 1 {
+		return report.Report{}, fmt.Errorf("cannot mix %s and %s inputs in one report", formats[0], formats[1])
+	}
+
+	if len(inputs.LCOV) > 0 {
+		return report.GenerateFromLCOV(inputs.LCOV, options)
+	}
+	if len(inputs.Cobertura.Packages) > 0 {
+		return report.GenerateFromCobertura(inputs.Cobertura, options)
+	}
+
+	reportData, err := report.GenerateFromProfiles(inputs.Profiles, options)
+	if err != nil {
+		return report.Report{}, err
+	}
+	reportData.CoverData = coverData
+
+	return reportData, nil
+}
+
+func parseBands(spec string) ([]report.Band, error) {
+	if spec == "" {
+		return nil, nil
+	}
+	bands, err := report.ParseBands(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -bands: %w", err)
+	}
+	return bands, nil
+}
+
+func parsePathMappings(values []string) ([]report.PathMapping, error) {
+	mappings := make([]report.PathMapping, 0, len(values))
+	for _, value := range values {
+		mapping, err := report.ParsePathMapping(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -path-map: %w", err)
+		}
+		mappings = append(mappings, mapping)
+	}
+	return mappings, nil
+}
+
+func expandProfiles(patterns []string) ([]string, error) {
+	seen := make(map[string]bool)
+	paths := make([]string, 0, len(patterns))
+
+	for _, pattern := range patterns {
+		if pattern == "" {
+			return nil, fmt.Errorf("-profile cannot be empty")
+		}
+
+		matches := []string{pattern}
+		if strings.ContainsAny(pattern, "*?[") {
+			globbed, err := filepath.Glob(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid profile pattern %s: %w", pattern, err)
+			}
+			if len(globbed) == 0 {
+				return nil, fmt.Errorf("no profiles match %s", pattern)
+			}
+			matches = globbed
+		}
+
+		for _, match := range matches {
+			if seen[match] {
+				continue
+			}
+			seen[match] = true
+			paths = append(paths, match)
+		}
+	}
+
+	return paths, nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// runMainEnv makes the test binary run main instead of the tests, so that
+// its exit code can be checked.
+const runMainEnv = "BEAUTIFUL_COVERAGE_RUN_MAIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(runMainEnv) == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func runMain(t *testing.T, args ...string) (int, string) {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(), runMainEnv+"=1")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), stderr.String()
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return 0, stderr.String()
+}
+
+func TestMainThresholds(t *testing.T) {
+	dir := t.TempDir()
+	// a/x.go 3/4 (75%) and b/z.go 2/3 (66.7%), a total of 5/7 (71.4%).
+	profile := "mode: set\na/x.go:1.1,1.2 3 1\na/x.go:2.1,2.2 1 0\nb/z.go:1.1,1.2 2 1\nb/z.go:2.1,2.2 1 0\n"
+	profilePath := filepath.Join(dir, "coverage.out")
+	if err := os.WriteFile(profilePath, []byte(profile), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantStderr string
+	}{
+		{
+			name: "at the minimums",
+			args: []string{"-min-total", "71.4", "-min-package", "66.7", "-min-file", "66.7"},
+		},
+		{
+			name:       "total below",
+			args:       []string{"-min-total", "71.5"},
+			wantCode:   1,
+			wantStderr: "coverage below threshold (1):\n  total Go Coverage Report: coverage 71.4% is below minimum 71.5%\n",
+		},
+		{
+			name:     "package and file below",
+			args:     []string{"-min-package", "66.8", "-min-file", "66.8"},
+			wantCode: 1,
+			wantStderr: "coverage below threshold (2):\n" +
+				"  package b: coverage 66.7% is below minimum 66.8%\n" +
+				"  file b/z.go: coverage 66.7% is below minimum 66.8%\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			args := append([]string{"-config", noConfig, "-profile", profilePath, "-format", "json=" + filepath.Join(t.TempDir(), "coverage.json")}, test.args...)
+			code, stderr := runMain(t, args...)
+			if code != test.wantCode {
+				t.Errorf("exit code = %d, want %d; stderr:\n%s", code, test.wantCode, stderr)
+			}
+			if stderr != test.wantStderr {
+				t.Errorf("stderr =\n%s\nwant\n%s", stderr, test.wantStderr)
+			}
+		})
+	}
+}
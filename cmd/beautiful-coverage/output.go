@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/beardnick/go-test-coverage/internal/render"
+	"github.com/beardnick/go-test-coverage/internal/report"
+)
+
+// stdoutPath writes an output to standard output.
+const stdoutPath = "-"
+
+// output is a report format and the file it is written to.
+type output struct {
+	format string
+	path   string
+}
+
+// defaultOutputPaths are the files each format is written to when neither
+// the -format entry nor -out names one.
+var defaultOutputPaths = map[string]string{
+	"html":      "coverage.html",
+	"json":      "coverage.json",
+	"markdown":  "coverage.md",
+	"cobertura": "coverage.xml",
+	"lcov":      "lcov.info",
+	"badge":     "coverage.svg",
+	"text":      stdoutPath,
+}
+
+// renderOptions holds the format specific flags.
+type renderOptions struct {
+	html     render.HTMLOptions
+	json     render.JSONOptions
+	markdown render.MarkdownOptions
+	badge    badgeOptions
+	text     render.TextOptions
+	color    string
+}
+
+// Color modes of the text format.
+const (
+	colorAuto   = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+)
+
+// badgeOptions configures the badge format. packages are tree directories
+// that get a badge of their own, as "dir" or "dir=path".
+type badgeOptions struct {
+	label    string
+	packages []string
+}
+
+// parseOutputs parses -format entries such as "html" or "json=report.json",
+// given as repeated flags or comma separated. outPath, when set, is used by
+// the first format without a path of its own.
+func parseOutputs(specs []string, outPath string) ([]output, error) {
+	if len(specs) == 0 {
+		specs = []string{"html"}
+	}
+
+	outputs := make([]output, 0, len(specs))
+	seen := make(map[string]bool)
+	for _, spec := range specs {
+		for _, entry := range strings.Split(spec, ",") {
+			format, path, hasPath := strings.Cut(strings.TrimSpace(entry), "=")
+			defaultPath, known := defaultOutputPaths[format]
+			if !known {
+				return nil, fmt.Errorf("unknown format %q, expected one of %s", format, strings.Join(outputFormats(), ", "))
+			}
+			if seen[format] {
+				return nil, fmt.Errorf("format %s given more than once", format)
+			}
+			seen[format] = true
+
+			if !hasPath {
+				path = defaultPath
+				if outPath != "" {
+					path = outPath
+					outPath = ""
+				}
+			}
+			if path == "" {
+				return nil, fmt.Errorf("format %s: empty output path", format)
+			}
+			outputs = append(outputs, output{format: format, path: path})
+		}
+	}
+
+	return outputs, nil
+}
+
+func outputFormats() []string {
+	formats := make([]string, 0, len(defaultOutputPaths))
+	for format := range defaultOutputPaths {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+func writeOutput(target output, reportData report.Report, options renderOptions) error {
+	if target.format == "badge" {
+		return writeBadges(target.path, reportData, options.badge)
+	}
+
+	return writeFile(target.path, func(writer io.Writer) error {
+		switch target.format {
+		case "html":
+			return render.HTML(writer, reportData, options.html)
+		case "json":
+			return render.JSON(writer, reportData, options.json)
+		case "markdown":
+			return render.Markdown(writer, reportData, options.markdown)
+		case "cobertura":
+			return render.Cobertura(writer, reportData)
+		case "lcov":
+			return render.LCOV(writer, reportData)
+		case "text":
+			textOptions := options.text
+			textOptions.Color = useColor(options.color, target.path)
+			return render.Text(writer, reportData, textOptions)
+		default:
+			return fmt.Errorf("unknown format %q", target.format)
+		}
+	})
+}
+
+// useColor decides whether text output to outputPath is colored. In auto
+// mode, only a terminal on standard output gets colors, unless NO_COLOR is
+// set.
+func useColor(mode, outputPath string) bool {
+	switch mode {
+	case colorAlways:
+		return true
+	case colorNever:
+		return false
+	}
+	if _, ok := os.LookupEnv("NO_COLOR"); ok || outputPath != stdoutPath || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// writeBadges writes the total coverage badge to outputPath and one badge
+// per package. A package badge without a path of its own is written next to
+// the total badge, e.g. coverage-pkg-a.svg for pkg/a.
+func writeBadges(outputPath string, reportData report.Report, options badgeOptions) error {
+	if err := writeFile(outputPath, func(writer io.Writer) error {
+		return render.Badge(writer, reportData.Bands, options.label, reportData.TotalCoveragePercent, reportData.TotalCoverageClass)
+	}); err != nil {
+		return err
+	}
+
+	for _, spec := range options.packages {
+		dir, badgePath, hasPath := strings.Cut(spec, "=")
+		dir = strings.Trim(filepath.ToSlash(dir), "/")
+		node := findDirectory(reportData.Tree, dir)
+		if node == nil {
+			return fmt.Errorf("badge package %s: no such directory in the report", dir)
+		}
+		if !hasPath {
+			if outputPath == stdoutPath {
+				return fmt.Errorf("badge package %s: a path is required when the badge is written to stdout", dir)
+			}
+			badgePath = strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + "-" + strings.ReplaceAll(dir, "/", "-") + ".svg"
+		}
+
+		if err := writeFile(badgePath, func(writer io.Writer) error {
+			return render.Badge(writer, reportData.Bands, options.label, node.CoveragePercent, node.CoverageClass)
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// findDirectory returns the tree directory with the slash separated path.
+func findDirectory(nodes []report.TreeNode, path string) *report.TreeNode {
+	for index := range nodes {
+		node := &nodes[index]
+		if !node.IsDir {
+			continue
+		}
+		if node.Path == path {
+			return node
+		}
+		if strings.HasPrefix(path, node.Path+"/") {
+			return findDirectory(node.Children, path)
+		}
+	}
+	return nil
+}
+
+// writeFile creates outputPath and writes it with write. A path of "-"
+// writes to standard output.
+func writeFile(outputPath string, write func(io.Writer) error) error {
+	if outputPath == stdoutPath {
+		return write(os.Stdout)
+	}
+
+	outputFile, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+
+	if err := write(outputFile); err != nil {
+		outputFile.Close()
+		return err
+	}
+
+	return outputFile.Close()
+}
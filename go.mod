@@ -3,3 +3,5 @@ module github.com/beardnick/go-test-coverage
 go 1.20
 
 require golang.org/x/tools v0.21.0
+
+require gopkg.in/yaml.v3 v3.0.1
@@ -0,0 +1,362 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/beardnick/go-test-coverage/internal/report"
+)
+
+// FileNames are the configuration files looked up in every directory, in
+// order of preference. JSON is read with the YAML parser.
+var FileNames = []string{".beautiful-coverage.yaml", ".beautiful-coverage.yml", ".beautiful-coverage.json"}
+
+// Config is a project configuration file. Every field corresponds to the
+// command line flag with the same name.
+type Config struct {
+	Profiles         []string      `yaml:"profiles"`
+	InputFormat      string        `yaml:"input-format"`
+	CoverDirs        []string      `yaml:"coverdirs"`
+	Root             string        `yaml:"root"`
+	Title            string        `yaml:"title"`
+	Include          []string      `yaml:"include"`
+	Exclude          []string      `yaml:"exclude"`
+	IncludeGenerated bool          `yaml:"include-generated"`
+	IncludeUntested  bool          `yaml:"include-untested"`
+	UntestedPattern  string        `yaml:"untested-pattern"`
+	PathMappings     []PathMapping `yaml:"path-mappings"`
+	Bands            []Band        `yaml:"bands"`
+	Thresholds       Thresholds    `yaml:"thresholds"`
+	History          string        `yaml:"history"`
+	Output           Output        `yaml:"output"`
+	OutDir           string        `yaml:"out-dir"`
+}
+
+// PathMapping rewrites file names starting with From to start with To.
+type PathMapping struct {
+	From string `yaml:"from"`
+	To   string `yaml:"to"`
+}
+
+// Band is a coverage band as in report.Band.
+type Band struct {
+	Name  string  `yaml:"name"`
+	Min   float64 `yaml:"min"`
+	Color string  `yaml:"color"`
+}
+
+// Thresholds are the minimum coverage percentages, 0 disables a check.
+type Thresholds struct {
+	Total   float64 `yaml:"total"`
+	Package float64 `yaml:"package"`
+	File    float64 `yaml:"file"`
+}
+
+// Output lists the files the report is written to, by format.
+type Output struct {
+	HTML      string `yaml:"html"`
+	JSON      string `yaml:"json"`
+	Markdown  string `yaml:"markdown"`
+	Cobertura string `yaml:"cobertura"`
+	LCOV      string `yaml:"lcov"`
+	Badge     string `yaml:"badge"`
+	Text      string `yaml:"text"`
+}
+
+// Setting is a configured value for the command line flag Name, in the
+// syntax the flag accepts.
+type Setting struct {
+	Name  string
+	Value string
+}
+
+// Find walks up from dir and returns the path of the first configuration
+// file found, or an empty string.
+func Find(dir string) (string, error) {
+	current, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		for _, name := range FileNames {
+			candidate := filepath.Join(current, name)
+			info, err := os.Stat(candidate)
+			if err == nil && !info.IsDir() {
+				return candidate, nil
+			}
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return "", err
+			}
+		}
+
+		parent := filepath.Dir(current)
+		if parent == current {
+			return "", nil
+		}
+		current = parent
+	}
+}
+
+// Load reads and validates the configuration file at path. Relative paths in
+// the file are resolved from its directory.
+func Load(path string) (Config, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("read config: %w", err)
+	}
+
+	config, err := Parse(content)
+	if err != nil {
+		return Config{}, fmt.Errorf("%s: %w", path, err)
+	}
+	config.resolvePaths(filepath.Dir(path))
+
+	return config, nil
+}
+
+// Parse decodes a configuration document. Unknown keys and values of the
+// wrong type or outside their valid range are reported with their line.
+func Parse(content []byte) (Config, error) {
+	var config Config
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&config); err != nil {
+		if errors.Is(err, io.EOF) {
+			return Config{}, nil
+		}
+		return Config{}, formatYAMLError(err)
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return Config{}, formatYAMLError(err)
+	}
+	if err := config.validate(&document); err != nil {
+		return Config{}, err
+	}
+
+	return config, nil
+}
+
+// formatYAMLError flattens the "yaml: unmarshal errors:" list into
+// "line N: message" entries separated by semicolons.
+func formatYAMLError(err error) error {
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		return errors.New(strings.Join(typeErr.Errors, "; "))
+	}
+	return errors.New(strings.TrimPrefix(err.Error(), "yaml: "))
+}
+
+func (config Config) validate(document *yaml.Node) error {
+	type problem struct {
+		line    int
+		message string
+	}
+	problems := make([]problem, 0)
+	fail := func(keys []interface{}, format string, args ...interface{}) {
+		problems = append(problems, problem{line: lineOf(document, keys...), message: fmt.Sprintf(format, args...)})
+	}
+
+	if _, err := report.ParseInputFormat(config.InputFormat); err != nil {
+		fail([]interface{}{"input-format"}, "input-format: %v", err)
+	}
+
+	for index, mapping := range config.PathMappings {
+		if mapping.From == "" {
+			fail([]interface{}{"path-mappings", index}, "path-mappings: from cannot be empty")
+		}
+		if mapping.To == "" {
+			fail([]interface{}{"path-mappings", index}, "path-mappings: to cannot be empty")
+		}
+	}
+
+	if len(config.Bands) > 0 {
+		if _, err := report.ParseBands(config.bandSpec()); err != nil {
+			fail([]interface{}{"bands"}, "bands: %v", err)
+		}
+	}
+
+	thresholds := []struct {
+		key   string
+		value float64
+	}{
+		{"total", config.Thresholds.Total},
+		{"package", config.Thresholds.Package},
+		{"file", config.Thresholds.File},
+	}
+	for _, threshold := range thresholds {
+		if threshold.value < 0 || threshold.value > 100 {
+			fail([]interface{}{"thresholds", threshold.key}, "thresholds.%s: %v is outside 0-100", threshold.key, threshold.value)
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].line < problems[j].line
+	})
+	messages := make([]string, 0, len(problems))
+	for _, problem := range problems {
+		messages = append(messages, fmt.Sprintf("line %d: %s", problem.line, problem.message))
+	}
+	return errors.New(strings.Join(messages, "; "))
+}
+
+// lineOf returns the line of the value at the path of mapping keys and
+// sequence indexes, or of the deepest part of it that exists.
+func lineOf(document *yaml.Node, keys ...interface{}) int {
+	node := document
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+
+	for _, key := range keys {
+		var next *yaml.Node
+		switch typed := key.(type) {
+		case string:
+			if node.Kind == yaml.MappingNode {
+				for index := 0; index+1 < len(node.Content); index += 2 {
+					if node.Content[index].Value == typed {
+						next = node.Content[index+1]
+						break
+					}
+				}
+			}
+		case int:
+			if node.Kind == yaml.SequenceNode && typed < len(node.Content) {
+				next = node.Content[typed]
+			}
+		}
+		if next == nil {
+			break
+		}
+		node = next
+	}
+
+	return node.Line
+}
+
+func (config *Config) resolvePaths(dir string) {
+	resolve := func(path string) string {
+		if path == "" || path == report.StdinPath || filepath.IsAbs(path) {
+			return path
+		}
+		return filepath.Join(dir, path)
+	}
+
+	for index := range config.Profiles {
+		config.Profiles[index] = resolve(config.Profiles[index])
+	}
+	for index := range config.CoverDirs {
+		config.CoverDirs[index] = resolve(config.CoverDirs[index])
+	}
+	for index := range config.PathMappings {
+		to := config.PathMappings[index].To
+		resolved := resolve(to)
+		// To replaces a prefix, so its trailing separator must survive.
+		if resolved != to && (strings.HasSuffix(to, "/") || strings.HasSuffix(to, string(filepath.Separator))) {
+			resolved += string(filepath.Separator)
+		}
+		config.PathMappings[index].To = resolved
+	}
+	config.Root = resolve(config.Root)
+	config.History = resolve(config.History)
+	config.OutDir = resolve(config.OutDir)
+	config.Output.HTML = resolve(config.Output.HTML)
+	config.Output.JSON = resolve(config.Output.JSON)
+	config.Output.Markdown = resolve(config.Output.Markdown)
+	config.Output.Cobertura = resolve(config.Output.Cobertura)
+	config.Output.LCOV = resolve(config.Output.LCOV)
+	config.Output.Badge = resolve(config.Output.Badge)
+	config.Output.Text = resolve(config.Output.Text)
+}
+
+// bandSpec formats the bands in the syntax of report.ParseBands.
+func (config Config) bandSpec() string {
+	entries := make([]string, 0, len(config.Bands))
+	for _, band := range config.Bands {
+		entry := band.Name + "=" + strconv.FormatFloat(band.Min, 'f', -1, 64)
+		if band.Color != "" {
+			entry += ":" + band.Color
+		}
+		entries = append(entries, entry)
+	}
+	return strings.Join(entries, ",")
+}
+
+// Settings returns the configured values as command line flag values. List
+// flags get one setting per element.
+func (config Config) Settings() []Setting {
+	settings := make([]Setting, 0)
+	add := func(name, value string) {
+		if value != "" {
+			settings = append(settings, Setting{Name: name, Value: value})
+		}
+	}
+	addBool := func(name string, value bool) {
+		if value {
+			add(name, "true")
+		}
+	}
+	addFloat := func(name string, value float64) {
+		if value != 0 {
+			add(name, strconv.FormatFloat(value, 'f', -1, 64))
+		}
+	}
+
+	for _, profile := range config.Profiles {
+		add("profile", profile)
+	}
+	add("input-format", config.InputFormat)
+	for _, dir := range config.CoverDirs {
+		add("coverdir", dir)
+	}
+	add("root", config.Root)
+	add("title", config.Title)
+	for _, pattern := range config.Include {
+		add("include", pattern)
+	}
+	for _, pattern := range config.Exclude {
+		add("exclude", pattern)
+	}
+	addBool("include-generated", config.IncludeGenerated)
+	addBool("include-untested", config.IncludeUntested)
+	add("untested-pattern", config.UntestedPattern)
+	for _, mapping := range config.PathMappings {
+		add("path-map", mapping.From+"="+mapping.To)
+	}
+	add("bands", config.bandSpec())
+	addFloat("min-total", config.Thresholds.Total)
+	addFloat("min-package", config.Thresholds.Package)
+	addFloat("min-file", config.Thresholds.File)
+	add("history", config.History)
+	addOutput := func(format, path string) {
+		if path != "" {
+			add("format", format+"="+path)
+		}
+	}
+	addOutput("html", config.Output.HTML)
+	addOutput("json", config.Output.JSON)
+	addOutput("markdown", config.Output.Markdown)
+	addOutput("cobertura", config.Output.Cobertura)
+	addOutput("lcov", config.Output.LCOV)
+	addOutput("badge", config.Output.Badge)
+	addOutput("text", config.Output.Text)
+	add("out-dir", config.OutDir)
+
+	return settings
+}
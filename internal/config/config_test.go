@@ -0,0 +1,132 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseDefaultFile(t *testing.T) {
+	if _, err := Parse([]byte(DefaultFile)); err != nil {
+		t.Fatalf("Parse(DefaultFile) error = %v", err)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "unknown key",
+			content: "title: x\nprofile: a.out\n",
+			wantErr: "line 2: field profile not found in type config.Config",
+		},
+		{
+			name:    "wrong type",
+			content: "title: x\nthresholds:\n  total: high\n",
+			wantErr: "line 3: cannot unmarshal !!str `high` into float64",
+		},
+		{
+			name:    "invalid input format",
+			content: "title: x\ninput-format: xml\n",
+			wantErr: "line 2: input-format: ",
+		},
+		{
+			name:    "empty path mapping",
+			content: "path-mappings:\n  - from: /ci/\n    to: ./\n  - from: /other/\n",
+			wantErr: "line 4: path-mappings: to cannot be empty",
+		},
+		{
+			name:    "invalid bands",
+			content: "title: x\n\nbands:\n  - name: swatch\n    min: 0\n    color: red\n",
+			wantErr: `line 4: bands: invalid band name "swatch"`,
+		},
+		{
+			name:    "threshold out of range",
+			content: "thresholds:\n  total: 50\n  file: 120\n",
+			wantErr: "line 3: thresholds.file: 120 is outside 0-100",
+		},
+		{
+			name:    "problems sorted by line",
+			content: "thresholds:\n  package: -1\ninput-format: xml\n",
+			wantErr: "line 2: thresholds.package: -1 is outside 0-100; line 3: input-format: ",
+		},
+		{
+			name:    "syntax error",
+			content: "title: [x\n",
+			wantErr: "line 1: ",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Parse([]byte(test.content))
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Fatalf("Parse() error = %v, want %q", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadResolvesPaths(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, FileNames[0])
+	content := `profiles:
+  - coverage.out
+  - /abs/unit.out
+  - "-"
+root: src
+history: history.jsonl
+path-mappings:
+  - from: /home/runner/work/project/
+    to: ./
+  - from: example.com/vendored/
+    to: third_party/vendored
+  - from: /ci/
+    to: /local/
+output:
+  html: out/coverage.html
+  text: "-"
+out-dir: site
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	separator := string(filepath.Separator)
+	if want := []string{filepath.Join(dir, "coverage.out"), "/abs/unit.out", "-"}; !reflect.DeepEqual(cfg.Profiles, want) {
+		t.Errorf("Profiles = %v, want %v", cfg.Profiles, want)
+	}
+	if want := filepath.Join(dir, "src"); cfg.Root != want {
+		t.Errorf("Root = %q, want %q", cfg.Root, want)
+	}
+	if want := filepath.Join(dir, "history.jsonl"); cfg.History != want {
+		t.Errorf("History = %q, want %q", cfg.History, want)
+	}
+	wantMappings := []PathMapping{
+		{From: "/home/runner/work/project/", To: dir + separator},
+		{From: "example.com/vendored/", To: filepath.Join(dir, "third_party", "vendored")},
+		{From: "/ci/", To: "/local/"},
+	}
+	if !reflect.DeepEqual(cfg.PathMappings, wantMappings) {
+		t.Errorf("PathMappings = %v, want %v", cfg.PathMappings, wantMappings)
+	}
+	if want := filepath.Join(dir, "out", "coverage.html"); cfg.Output.HTML != want {
+		t.Errorf("Output.HTML = %q, want %q", cfg.Output.HTML, want)
+	}
+	if cfg.Output.Text != "-" {
+		t.Errorf("Output.Text = %q, want -", cfg.Output.Text)
+	}
+	if want := filepath.Join(dir, "site"); cfg.OutDir != want {
+		t.Errorf("OutDir = %q, want %q", cfg.OutDir, want)
+	}
+}
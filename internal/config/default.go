@@ -0,0 +1,82 @@
+package config
+
+// DefaultFile is the documented configuration written by `config init`. Its
+// values are the command line defaults.
+const DefaultFile = `# Configuration of beautiful-coverage.
+#
+# The file is looked up in the working directory and its parents. Command
+# line flags override the values below; relative paths are resolved from the
+# directory of this file.
+
+# Coverage files or globs: Go coverprofiles, LCOV tracefiles or Cobertura XML.
+profiles:
+  - coverage.out
+
+# Format of the profiles: auto, go, lcov or cobertura.
+input-format: auto
+
+# GOCOVERDIR directories written by binaries built with "go build -cover".
+coverdirs: []
+
+# Directory used to resolve source files. Defaults to the directory of the
+# first profile.
+root: ""
+
+title: Go Coverage Report
+
+# Files or packages to report, as globs or regular expressions prefixed with
+# "re:". An empty include list reports everything.
+include: []
+exclude: []
+#  - "*.pb.go"
+#  - "**/mocks/**"
+
+# Keep files with a "// Code generated ... DO NOT EDIT." header.
+include-generated: false
+
+# Add packages without coverage data, matched by untested-pattern, as
+# uncovered files.
+include-untested: false
+untested-pattern: ./...
+
+# Rewrite file names from the coverage data before they are resolved, e.g.
+# the checkout directory of a CI machine.
+path-mappings: []
+#  - from: /home/runner/work/project/project/
+#    to: ./
+
+# Coverage bands from the highest minimum down; the lowest must start at 0.
+bands:
+  - name: high
+    min: 90
+  - name: medium
+    min: 75
+  - name: low
+    min: 0.1
+  - name: none
+    min: 0
+
+# Minimum coverage percentages. The command exits with status 1 when one is
+# not met; 0 disables a check.
+thresholds:
+  total: 0
+  package: 0
+  file: 0
+
+# JSON-lines file every run is appended to, for trend charts.
+history: ""
+
+# Report files by format.
+output:
+  html: coverage.html
+#  json: coverage.json
+#  markdown: coverage.md
+#  cobertura: coverage.xml
+#  lcov: lcov.info
+#  badge: coverage.svg
+#  text: "-"
+
+# Directory to write the HTML report to as a site with one page per file,
+# for repositories too large for a single page.
+out-dir: ""
+`
@@ -13,14 +13,15 @@ import (
 
 const assetsRoot = "assets"
 
-//go:embed assets/highlight/*
+//go:embed assets/report.css assets/report.js assets/virtual.js assets/highlight/*
 var embeddedAssets embed.FS
 
 type InlineAssets struct {
 	HighlightDarkCSS  string
 	HighlightLightCSS string
-	HighlightJS       string
-	HighlightGoJS     string
+	ReportCSS         string
+	ReportJS          string
+	VirtualJS         string
 }
 
 func LoadInlineAssets() (InlineAssets, error) {
@@ -34,12 +35,17 @@ func LoadInlineAssets() (InlineAssets, error) {
 		return InlineAssets{}, err
 	}
 
-	highlightJS, err := readAsset(path.Join(assetsRoot, "highlight", "highlight.min.js"))
+	reportCSS, err := readAsset(path.Join(assetsRoot, "report.css"))
 	if err != nil {
 		return InlineAssets{}, err
 	}
 
-	goJS, err := readAsset(path.Join(assetsRoot, "highlight", "go.min.js"))
+	reportJS, err := readAsset(path.Join(assetsRoot, "report.js"))
+	if err != nil {
+		return InlineAssets{}, err
+	}
+
+	virtualJS, err := readAsset(path.Join(assetsRoot, "virtual.js"))
 	if err != nil {
 		return InlineAssets{}, err
 	}
@@ -47,8 +53,9 @@ func LoadInlineAssets() (InlineAssets, error) {
 	return InlineAssets{
 		HighlightDarkCSS:  dark,
 		HighlightLightCSS: light,
-		HighlightJS:       highlightJS,
-		HighlightGoJS:     goJS,
+		ReportCSS:         reportCSS,
+		ReportJS:          reportJS,
+		VirtualJS:         virtualJS,
 	}, nil
 }
 
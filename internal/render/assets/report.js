@@ -0,0 +1,231 @@
+const sections = Array.from(document.querySelectorAll('.file-section'));
+const filters = document.querySelectorAll('[data-filter]');
+const fileNodes = Array.from(document.querySelectorAll('.file-node'));
+const treeDetails = Array.from(document.querySelectorAll('.tree-dir details'));
+const treeToggle = document.getElementById('toggle-tree');
+const currentFile = document.getElementById('current-file');
+const themeToggle = document.getElementById('theme-toggle');
+const highlightDark = document.getElementById('highlight-dark');
+const highlightLight = document.getElementById('highlight-light');
+// Set by virtual.js when the files are rendered from embedded data.
+const virtualReport = window.virtualReport;
+
+function applyTheme(theme) {
+  const useLight = theme === 'light';
+  document.body.classList.toggle('theme-light', useLight);
+  if (highlightDark && highlightLight) {
+    highlightDark.disabled = useLight;
+    highlightLight.disabled = !useLight;
+  }
+  if (themeToggle) {
+    themeToggle.textContent = useLight ? 'Dark theme' : 'Light theme';
+    themeToggle.setAttribute('aria-pressed', useLight ? 'true' : 'false');
+  }
+  try {
+    localStorage.setItem('theme', theme);
+  } catch (err) {
+    // Ignore storage failures (private mode, etc.).
+  }
+}
+
+function initTheme() {
+  let theme = 'dark';
+  try {
+    const stored = localStorage.getItem('theme');
+    if (stored === 'light' || stored === 'dark') {
+      theme = stored;
+    } else if (window.matchMedia && window.matchMedia('(prefers-color-scheme: light)').matches) {
+      theme = 'light';
+    }
+  } catch (err) {
+    // Ignore storage failures and fall back to default theme.
+  }
+  applyTheme(theme);
+}
+
+function updateTreeToggleLabel() {
+  if (!treeToggle) {
+    return;
+  }
+  const allOpen = treeDetails.length > 0 && treeDetails.every((item) => item.open);
+  treeToggle.textContent = allOpen ? 'Collapse all' : 'Expand all';
+  treeToggle.setAttribute('aria-expanded', allOpen ? 'true' : 'false');
+  treeToggle.disabled = treeDetails.length === 0;
+}
+
+function setAllTree(open) {
+  treeDetails.forEach((item) => {
+    item.open = open;
+  });
+}
+
+function hasSection(anchor) {
+  if (virtualReport) {
+    return fileNodes.some((node) => node.dataset.anchor === anchor);
+  }
+  return sections.some((section) => section.id === anchor);
+}
+
+function setCurrent(anchor) {
+  const node = fileNodes.find((item) => item.dataset.anchor === anchor);
+  if (!node) {
+    return;
+  }
+  const name = node.dataset.name || anchor;
+  const coverage = node.dataset.coverage;
+  if (currentFile) {
+    currentFile.textContent = coverage ? name + ' (' + coverage + ')' : name;
+  }
+  fileNodes.forEach((item) => {
+    item.classList.toggle('active', item.dataset.anchor === anchor);
+  });
+}
+
+function activate(anchor, updateHash) {
+  if (virtualReport) {
+    virtualReport.show(anchor);
+  }
+  sections.forEach((section) => {
+    section.classList.toggle('active', section.id === anchor);
+  });
+  setCurrent(anchor);
+  if (updateHash) {
+    history.replaceState(null, '', '#' + anchor);
+  }
+}
+
+function syncFromHash() {
+  const hash = window.location.hash.replace('#', '');
+  const line = /^L(\d+)$/.exec(hash);
+  if (line && sections.length === 1) {
+    jumpToLine(sections[0].id, Number(line[1]), false);
+    return;
+  }
+  if (hash && hasSection(hash)) {
+    activate(hash, false);
+    return;
+  }
+  if (virtualReport && virtualReport.first) {
+    activate(virtualReport.first, false);
+  } else if (sections.length > 0) {
+    activate(sections[0].id, false);
+  }
+}
+
+if (sections.length > 0 || virtualReport) {
+  syncFromHash();
+  window.addEventListener('hashchange', syncFromHash);
+}
+
+if (treeToggle) {
+  treeToggle.addEventListener('click', () => {
+    const allOpen = treeDetails.length > 0 && treeDetails.every((item) => item.open);
+    setAllTree(!allOpen);
+    updateTreeToggleLabel();
+  });
+  treeDetails.forEach((item) => {
+    item.addEventListener('toggle', updateTreeToggleLabel);
+  });
+  updateTreeToggleLabel();
+}
+
+if (themeToggle) {
+  themeToggle.addEventListener('click', () => {
+    const isLight = document.body.classList.contains('theme-light');
+    applyTheme(isLight ? 'dark' : 'light');
+  });
+}
+
+initTheme();
+
+fileNodes.forEach((node) => {
+  node.addEventListener('click', () => {
+    // Site index pages link to the file pages instead.
+    if (hasSection(node.dataset.anchor)) {
+      activate(node.dataset.anchor, true);
+    }
+  });
+});
+
+function sortTable(table, columnIndex, type, ascending) {
+  const body = table.tBodies[0];
+  if (!body) {
+    return;
+  }
+  const rows = Array.from(body.rows);
+  rows.sort((left, right) => {
+    const leftValue = left.cells[columnIndex].dataset.value || '';
+    const rightValue = right.cells[columnIndex].dataset.value || '';
+    let result;
+    if (type === 'number') {
+      result = parseFloat(leftValue) - parseFloat(rightValue);
+    } else {
+      result = leftValue.localeCompare(rightValue);
+    }
+    return ascending ? result : -result;
+  });
+  rows.forEach((row) => body.appendChild(row));
+}
+
+// Tables and links are handled by delegation, so that file sections
+// rendered later by virtual.js work as well.
+document.addEventListener('click', (event) => {
+  const header = event.target.closest('table.sortable th[data-sort]');
+  if (!header) {
+    return;
+  }
+  const table = header.closest('table');
+  const ascending = header.getAttribute('aria-sort') !== 'ascending';
+  table.querySelectorAll('th[data-sort]').forEach((item) => item.removeAttribute('aria-sort'));
+  header.setAttribute('aria-sort', ascending ? 'ascending' : 'descending');
+  sortTable(table, header.cellIndex, header.dataset.sort, ascending);
+});
+
+// jumpToLine shows the file with anchor scrolled to line. Pages of a single
+// file, such as site file pages, address the line as #L<line>, which
+// syncFromHash reads back; other pages address the file.
+function jumpToLine(anchor, line, updateHash) {
+  activate(anchor, false);
+  if (updateHash) {
+    history.replaceState(null, '', '#' + (sections.length === 1 ? 'L' + line : anchor));
+  }
+  if (virtualReport) {
+    virtualReport.jump(line);
+    return;
+  }
+  const section = document.getElementById(anchor);
+  if (!section) {
+    return;
+  }
+  const rows = section.querySelectorAll('.code-table tbody tr');
+  const row = rows[line - 1];
+  if (!row) {
+    return;
+  }
+  row.scrollIntoView({ block: 'center' });
+  row.classList.add('line-flash');
+  setTimeout(() => row.classList.remove('line-flash'), 1500);
+}
+
+document.addEventListener('click', (event) => {
+  const link = event.target.closest('.function-link');
+  if (!link || !hasSection(link.dataset.anchor)) {
+    return;
+  }
+  event.preventDefault();
+  jumpToLine(link.dataset.anchor, Number(link.dataset.line), true);
+});
+
+filters.forEach((filter) => {
+  filter.addEventListener('change', (event) => {
+    const key = event.target.getAttribute('data-filter');
+    document.body.classList.toggle('hide-' + key, !event.target.checked);
+  });
+});
+
+const changedOnly = document.getElementById('changed-only');
+if (changedOnly) {
+  changedOnly.addEventListener('change', () => {
+    document.body.classList.toggle('changed-only', changedOnly.checked);
+  });
+}
@@ -0,0 +1,347 @@
+// Renders the file sections of a report whose files are embedded as gzip
+// compressed JSON: only the selected file is built, and of its code only the
+// rows in view. Runs before report.js, which drives it through
+// window.virtualReport.
+(() => {
+  const dataElement = document.getElementById('report-data');
+  const viewerBody = document.getElementById('viewer-body');
+  if (!dataElement || !viewerBody) {
+    return;
+  }
+
+  // Rows rendered above and below the visible ones, so that scrolling does
+  // not show blank space before the next frame.
+  const overscan = 30;
+  let rowHeight = 20;
+  let data = null;
+  let filesByAnchor = new Map();
+  let pendingAnchor = '';
+  let pendingLine = 0;
+  let view = null;
+  let frame = 0;
+
+  window.virtualReport = {
+    first: dataElement.dataset.first || '',
+    show(anchor) {
+      if (!data) {
+        pendingAnchor = anchor;
+        return;
+      }
+      showFile(anchor);
+    },
+    jump(line) {
+      if (!data) {
+        pendingLine = line;
+        return;
+      }
+      jumpToLine(line);
+    },
+  };
+
+  const loading = element('div', 'loading', 'Loading report data…');
+  viewerBody.appendChild(loading);
+
+  decode(dataElement.textContent)
+    .then((decoded) => {
+      data = decoded;
+      filesByAnchor = new Map(data.files.map((file) => [file.anchor, file]));
+      loading.remove();
+      if (pendingAnchor) {
+        showFile(pendingAnchor);
+      }
+      if (pendingLine) {
+        jumpToLine(pendingLine);
+      }
+    })
+    .catch((err) => {
+      loading.className = 'missing';
+      loading.textContent = 'Cannot load the report data: ' + err.message;
+    });
+
+  async function decode(encoded) {
+    const binary = atob(encoded.trim());
+    const bytes = new Uint8Array(binary.length);
+    for (let i = 0; i < binary.length; i++) {
+      bytes[i] = binary.charCodeAt(i);
+    }
+    const stream = new Blob([bytes]).stream().pipeThrough(new DecompressionStream('gzip'));
+    return new Response(stream).json();
+  }
+
+  function element(tag, className, text) {
+    const node = document.createElement(tag);
+    if (className) {
+      node.className = className;
+    }
+    if (text !== undefined) {
+      node.textContent = text;
+    }
+    return node;
+  }
+
+  function percentText(value) {
+    return value.toFixed(1) + '%';
+  }
+
+  function showFile(anchor) {
+    const file = filesByAnchor.get(anchor);
+    if (!file || (view && view.file === file)) {
+      return;
+    }
+    if (view) {
+      view.section.remove();
+    }
+    const { section, code } = renderSection(file);
+    view = {
+      file,
+      section,
+      code,
+      lines: [],
+      start: -1,
+      end: -1,
+      flashLine: 0,
+    };
+    viewerBody.appendChild(section);
+    if (view.code) {
+      view.code.viewport.addEventListener('scroll', scheduleRender);
+      updateLines();
+    }
+  }
+
+  // renderSection builds the markup of the file template of html.go. The
+  // code table starts empty; renderRows fills it with the rows in view.
+  function renderSection(file) {
+    const section = element('section', 'file-section active' + (file.changed ? ' changed' : ''));
+    section.id = file.anchor;
+
+    const header = element('div', 'file-header');
+    header.appendChild(element('h2', '', file.name));
+    if (file.untested) {
+      const badge = element('span', 'untested-badge', 'no tests');
+      badge.title = 'package has no tests';
+      header.appendChild(badge);
+    }
+    if (file.belowThreshold) {
+      const badge = element('span', 'threshold-badge', 'below min');
+      badge.title = 'minimum ' + percentText(data.fileThreshold);
+      header.appendChild(badge);
+    }
+    if (file.delta) {
+      const badge = element('span', 'delta-badge ' + file.delta.class, file.delta.text);
+      if (file.delta.title) {
+        badge.title = file.delta.title;
+      }
+      header.appendChild(badge);
+    }
+    header.appendChild(element('span', 'pill band band-' + file.class, file.percent));
+    section.appendChild(header);
+
+    let statements = 'Covered ' + file.covered + ' / ' + file.total + ' statements';
+    if (file.ignored) {
+      statements += ', ' + file.ignored + ' ignored';
+    }
+    section.appendChild(element('div', '', statements));
+
+    const progress = element('div', 'progress');
+    progress.style.marginTop = '8px';
+    const bar = element('div', 'bar band band-' + file.class);
+    bar.style.width = file.percent;
+    progress.appendChild(bar);
+    section.appendChild(progress);
+
+    if (file.functions.length > 0) {
+      section.appendChild(renderFunctions(file));
+    }
+
+    if (file.missing) {
+      section.appendChild(element('div', 'missing', file.missingDescription));
+      return { section, code: null };
+    }
+
+    const viewport = element('div', 'code-viewport');
+    const before = element('div');
+    const table = element('table', 'code-table');
+    const body = document.createElement('tbody');
+    const after = element('div');
+    table.appendChild(body);
+    viewport.append(before, table, after);
+    section.appendChild(viewport);
+    return { section, code: { viewport, before, body, after } };
+  }
+
+  function renderFunctions(file) {
+    const details = element('details', 'functions');
+    details.appendChild(element('summary', '', 'Functions (' + file.functions.length + ')'));
+    const table = element('table', 'file-table sortable');
+    const head = document.createElement('thead');
+    const headRow = document.createElement('tr');
+    [['text', 'Function'], ['number', 'Lines'], ['number', 'Statements'], ['number', 'Coverage']].forEach(([sort, label]) => {
+      const cell = element('th', '', label);
+      cell.dataset.sort = sort;
+      headRow.appendChild(cell);
+    });
+    head.appendChild(headRow);
+    table.appendChild(head);
+
+    const body = document.createElement('tbody');
+    file.functions.forEach((fn) => {
+      const row = document.createElement('tr');
+      const name = element('td');
+      name.dataset.value = fn.name;
+      const link = element('a', 'function-link', fn.name);
+      link.href = '#' + file.anchor;
+      link.dataset.anchor = file.anchor;
+      link.dataset.line = fn.start;
+      name.appendChild(link);
+      const lines = element('td', '', fn.start + '-' + fn.end);
+      lines.dataset.value = fn.start;
+      const statements = element('td', '', fn.covered + ' / ' + fn.total);
+      statements.dataset.value = fn.total;
+      const coverage = element('td');
+      coverage.dataset.value = fn.percent;
+      coverage.appendChild(element('span', 'pill band band-' + fn.class, fn.percent));
+      row.append(name, lines, statements, coverage);
+      body.appendChild(row);
+    });
+    table.appendChild(body);
+    details.appendChild(table);
+    return details;
+  }
+
+  // updateLines collects the lines passing the filters of the viewer bar
+  // and renders them from the top.
+  function updateLines() {
+    if (!view || !view.code) {
+      return;
+    }
+    const hidden = new Set();
+    document.querySelectorAll('[data-filter]').forEach((filter) => {
+      if (!filter.checked) {
+        hidden.add(filter.dataset.filter);
+      }
+    });
+    const changedOnly = document.getElementById('changed-only');
+    const onlyChanged = changedOnly && changedOnly.checked;
+    view.lines = [];
+    view.file.lines.forEach((line, index) => {
+      if (hidden.has(line[1]) || (onlyChanged && !line[5])) {
+        return;
+      }
+      view.lines.push(index);
+    });
+    view.start = -1;
+    renderRows();
+  }
+
+  function scheduleRender() {
+    if (frame) {
+      return;
+    }
+    frame = requestAnimationFrame(() => {
+      frame = 0;
+      renderRows();
+    });
+  }
+
+  // renderRows renders the rows in view, with spacers standing in for the
+  // rows above and below.
+  function renderRows() {
+    if (!view || !view.code) {
+      return;
+    }
+    const code = view.code;
+    const visible = Math.ceil(code.viewport.clientHeight / rowHeight) || 1;
+    const start = Math.max(0, Math.floor(code.viewport.scrollTop / rowHeight) - overscan);
+    const end = Math.min(view.lines.length, start + visible + 2 * overscan);
+    if (start === view.start && end === view.end) {
+      return;
+    }
+    view.start = start;
+    view.end = end;
+
+    const fragment = document.createDocumentFragment();
+    for (let i = start; i < end; i++) {
+      fragment.appendChild(renderRow(view.lines[i]));
+    }
+    code.body.replaceChildren(fragment);
+
+    const first = code.body.rows[0];
+    if (first && first.offsetHeight > 0 && first.offsetHeight !== rowHeight) {
+      rowHeight = first.offsetHeight;
+      view.start = -1;
+      renderRows();
+      return;
+    }
+    code.before.style.height = start * rowHeight + 'px';
+    code.after.style.height = (view.lines.length - end) * rowHeight + 'px';
+  }
+
+  // renderRow builds the row of the line at index as the file template does:
+  // [highlighted code, class, max hits, total hits, heat, changed, branch
+  // summary].
+  function renderRow(index) {
+    const line = view.file.lines[index];
+    const number = index + 1;
+    let className = line[1];
+    if (data.hasHitCounts && line[4]) {
+      className += ' heat-' + line[4];
+    }
+    if (line[5]) {
+      className += ' changed';
+    }
+    if (number === view.flashLine) {
+      className += ' line-flash';
+    }
+    const row = element('tr', className);
+
+    const lineNumber = element('td', 'line-no', String(number));
+    if (line[6]) {
+      lineNumber.title = line[6];
+    }
+    row.appendChild(lineNumber);
+
+    if (data.hasHitCounts) {
+      const hits = element('td', 'hits');
+      if (line[1] !== 'not-tracked') {
+        hits.textContent = line[2];
+        hits.title = 'max ' + line[2] + ', total ' + line[3] + ' executions';
+      }
+      row.appendChild(hits);
+    }
+
+    const cell = element('td', 'code');
+    const code = element('code', 'hljs');
+    code.innerHTML = line[0];
+    cell.appendChild(code);
+    row.appendChild(cell);
+    return row;
+  }
+
+  function jumpToLine(line) {
+    if (!view || !view.code) {
+      return;
+    }
+    let position = view.lines.findIndex((index) => index >= line - 1);
+    if (position < 0) {
+      position = view.lines.length - 1;
+    }
+    const viewport = view.code.viewport;
+    view.flashLine = line;
+    viewport.scrollIntoView({ block: 'nearest' });
+    viewport.scrollTop = Math.max(0, (position + 0.5) * rowHeight - viewport.clientHeight / 2);
+    view.start = -1;
+    renderRows();
+    const flashed = view;
+    setTimeout(() => {
+      flashed.flashLine = 0;
+      flashed.code.body.querySelectorAll('.line-flash').forEach((row) => row.classList.remove('line-flash'));
+    }, 1500);
+  }
+
+  document.addEventListener('change', (event) => {
+    if (event.target.matches('[data-filter], #changed-only')) {
+      updateLines();
+    }
+  });
+  window.addEventListener('resize', scheduleRender);
+})();
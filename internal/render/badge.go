@@ -0,0 +1,113 @@
+package render
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/beardnick/go-test-coverage/internal/report"
+)
+
+// DefaultBadgeLabel is the left-hand text of coverage badges.
+const DefaultBadgeLabel = "coverage"
+
+// badgeColors resolves the theme variables used by the default bands to the
+// colors of the light report theme, since a standalone SVG has no stylesheet.
+var badgeColors = map[string]string{
+	"var(--covered)": "#16a34a",
+	"var(--partial)": "#d97706",
+	"var(--missed)":  "#dc2626",
+	"var(--muted)":   "#64748b",
+}
+
+// verdanaWidths are the advance widths of the printable ASCII characters in
+// Verdana, in units of 1/2048 em, starting at the space character. Badges
+// are drawn in Verdana at 11px like shields.io badges.
+var verdanaWidths = [...]int{
+	720, 823, 1002, 1716, 1302, 2248, 1477, 550, 1000, 1000, 1302, 1716, 741, 867, 741, 1000,
+	1302, 1302, 1302, 1302, 1302, 1302, 1302, 1302, 1302, 1302, 934, 934, 1716, 1716, 1716, 1114,
+	2048, 1401, 1405, 1430, 1577, 1294, 1178, 1587, 1540, 862, 940, 1432, 1144, 1722, 1524, 1622,
+	1237, 1622, 1458, 1401, 1249, 1526, 1401, 2038, 1404, 1249, 1405, 1000, 1000, 1000, 1716, 1302,
+	1302, 1229, 1274, 1067, 1274, 1216, 720, 1274, 1296, 562, 705, 1194, 562, 1992, 1296, 1236,
+	1274, 1274, 874, 1067, 807, 1296, 1194, 1673, 1194, 1194, 1053, 1303, 1000, 1303, 1716,
+}
+
+const (
+	badgeFontSize   = 11
+	badgeUnitsPerEm = 2048
+	// badgeWideWidth is used for characters outside printable ASCII.
+	badgeWideWidth = 2048
+	// badgePadding is the space left and right of each text.
+	badgePadding = 5
+)
+
+// Badge writes a flat shields.io style SVG badge showing label and the
+// coverage percent on the color of band class. The text is measured with
+// Verdana metrics and pinned with textLength, so the badge renders the same
+// without the font installed.
+func Badge(writer io.Writer, bands []report.Band, label, percent, class string) error {
+	labelWidth := badgeTextWidth(label) + 2*badgePadding
+	valueWidth := badgeTextWidth(percent) + 2*badgePadding
+	width := labelWidth + valueWidth
+	title := html.EscapeString(label + ": " + percent)
+
+	var svg strings.Builder
+	fmt.Fprintf(&svg, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="20" role="img" aria-label="%s">`+"\n", width, title)
+	fmt.Fprintf(&svg, "  <title>%s</title>\n", title)
+	svg.WriteString(`  <linearGradient id="s" x2="0" y2="100%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>` + "\n")
+	fmt.Fprintf(&svg, `  <clipPath id="r"><rect width="%d" height="20" rx="3" fill="#fff"/></clipPath>`+"\n", width)
+	svg.WriteString(`  <g clip-path="url(#r)">` + "\n")
+	fmt.Fprintf(&svg, `    <rect width="%d" height="20" fill="#555"/>`+"\n", labelWidth)
+	fmt.Fprintf(&svg, `    <rect x="%d" width="%d" height="20" fill="%s"/>`+"\n", labelWidth, valueWidth, html.EscapeString(badgeColor(bands, class)))
+	fmt.Fprintf(&svg, `    <rect width="%d" height="20" fill="url(#s)"/>`+"\n", width)
+	svg.WriteString("  </g>\n")
+	svg.WriteString(`  <g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" text-rendering="geometricPrecision" font-size="110">` + "\n")
+	writeBadgeText(&svg, label, 0, labelWidth)
+	writeBadgeText(&svg, percent, labelWidth, valueWidth)
+	svg.WriteString("  </g>\n</svg>\n")
+
+	_, err := io.WriteString(writer, svg.String())
+	return err
+}
+
+// writeBadgeText centers text in the section starting at x, with a shadow.
+// Coordinates are scaled by ten for sub-pixel placement.
+func writeBadgeText(svg *strings.Builder, text string, x, width int) {
+	center := x*10 + width*5
+	length := (width - 2*badgePadding) * 10
+	escaped := html.EscapeString(text)
+	fmt.Fprintf(svg, `    <text aria-hidden="true" x="%d" y="150" fill="#010101" fill-opacity=".3" transform="scale(.1)" textLength="%d">%s</text>`+"\n", center, length, escaped)
+	fmt.Fprintf(svg, `    <text x="%d" y="140" transform="scale(.1)" textLength="%d">%s</text>`+"\n", center, length, escaped)
+}
+
+// badgeTextWidth returns the width of text in pixels, rounded up.
+func badgeTextWidth(text string) int {
+	units := 0
+	for _, char := range text {
+		if char >= ' ' && int(char-' ') < len(verdanaWidths) {
+			units += verdanaWidths[char-' ']
+		} else {
+			units += badgeWideWidth
+		}
+	}
+	return int(math.Ceil(float64(units) * badgeFontSize / badgeUnitsPerEm))
+}
+
+// badgeColor returns the color of band name, resolving theme variables.
+func badgeColor(bands []report.Band, name string) string {
+	for _, band := range bands {
+		if band.Name != name {
+			continue
+		}
+		if color, ok := badgeColors[band.Color]; ok {
+			return color
+		}
+		if strings.HasPrefix(band.Color, "var(") {
+			break
+		}
+		return band.Color
+	}
+	return badgeColors["var(--muted)"]
+}
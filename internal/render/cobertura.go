@@ -0,0 +1,351 @@
+package render
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/beardnick/go-test-coverage/internal/report"
+)
+
+const coberturaDocType = `<!DOCTYPE coverage SYSTEM "http://cobertura.sourceforge.net/xml/coverage-04.dtd">`
+
+// rootPackage names the package of files directly in the root directory.
+const rootPackage = "."
+
+type coberturaCoverage struct {
+	XMLName         xml.Name           `xml:"coverage"`
+	LineRate        string             `xml:"line-rate,attr"`
+	BranchRate      string             `xml:"branch-rate,attr"`
+	LinesCovered    int                `xml:"lines-covered,attr"`
+	LinesValid      int                `xml:"lines-valid,attr"`
+	BranchesCovered int                `xml:"branches-covered,attr"`
+	BranchesValid   int                `xml:"branches-valid,attr"`
+	Complexity      int                `xml:"complexity,attr"`
+	Version         string             `xml:"version,attr"`
+	Timestamp       int64              `xml:"timestamp,attr"`
+	Sources         []string           `xml:"sources>source"`
+	Packages        []coberturaPackage `xml:"packages>package"`
+}
+
+type coberturaPackage struct {
+	Name       string           `xml:"name,attr"`
+	LineRate   string           `xml:"line-rate,attr"`
+	BranchRate string           `xml:"branch-rate,attr"`
+	Complexity int              `xml:"complexity,attr"`
+	Classes    []coberturaClass `xml:"classes>class"`
+}
+
+type coberturaClass struct {
+	Name       string            `xml:"name,attr"`
+	FileName   string            `xml:"filename,attr"`
+	LineRate   string            `xml:"line-rate,attr"`
+	BranchRate string            `xml:"branch-rate,attr"`
+	Complexity int               `xml:"complexity,attr"`
+	Methods    []coberturaMethod `xml:"methods>method"`
+	Lines      []coberturaLine   `xml:"lines>line"`
+}
+
+type coberturaMethod struct {
+	Name       string          `xml:"name,attr"`
+	Signature  string          `xml:"signature,attr"`
+	LineRate   string          `xml:"line-rate,attr"`
+	BranchRate string          `xml:"branch-rate,attr"`
+	Complexity int             `xml:"complexity,attr"`
+	Lines      []coberturaLine `xml:"lines>line"`
+}
+
+type coberturaLine struct {
+	Number            int    `xml:"number,attr"`
+	Hits              int    `xml:"hits,attr"`
+	Branch            bool   `xml:"branch,attr"`
+	ConditionCoverage string `xml:"condition-coverage,attr,omitempty"`
+}
+
+// coberturaCounts accumulates the line and branch totals of an element.
+type coberturaCounts struct {
+	linesCovered    int
+	linesValid      int
+	branchesCovered int
+	branchesValid   int
+}
+
+func (counts *coberturaCounts) add(other coberturaCounts) {
+	counts.linesCovered += other.linesCovered
+	counts.linesValid += other.linesValid
+	counts.branchesCovered += other.branchesCovered
+	counts.branchesValid += other.branchesValid
+}
+
+func (counts coberturaCounts) lineRate() string {
+	return coberturaRate(counts.linesCovered, counts.linesValid)
+}
+
+func (counts coberturaCounts) branchRate() string {
+	return coberturaRate(counts.branchesCovered, counts.branchesValid)
+}
+
+// Cobertura writes reportData as a Cobertura XML document, as read by GitLab
+// merge request annotations and the Jenkins Coverage plugin. Every tree
+// directory with files becomes a package and every file a class. Lines count
+// as valid when they hold statements; partial lines are marked as branches
+// with the share of their blocks that ran, or with the branch data of LCOV
+// and Cobertura inputs. Files whose source was not found keep the lines of
+// their blocks.
+func Cobertura(writer io.Writer, reportData report.Report) error {
+	filesByAnchor := make(map[string]*report.FileReport, len(reportData.Files))
+	for index := range reportData.Files {
+		filesByAnchor[reportData.Files[index].Anchor] = &reportData.Files[index]
+	}
+
+	document := coberturaCoverage{
+		Version:   "beautiful-coverage",
+		Timestamp: coberturaTimestamp(reportData.GeneratedAt),
+		Sources:   []string{filepath.ToSlash(reportData.Root)},
+		Packages:  make([]coberturaPackage, 0),
+	}
+
+	var total coberturaCounts
+	rootFiles := make([]report.TreeNode, 0)
+	for _, node := range reportData.Tree {
+		if !node.IsDir {
+			rootFiles = append(rootFiles, node)
+		}
+	}
+	if len(rootFiles) > 0 {
+		pkg, counts := newCoberturaPackage(rootPackage, rootFiles, filesByAnchor)
+		document.Packages = append(document.Packages, pkg)
+		total.add(counts)
+	}
+	collectCoberturaPackages(&document.Packages, &total, reportData.Tree, filesByAnchor)
+
+	document.LineRate = total.lineRate()
+	document.BranchRate = total.branchRate()
+	document.LinesCovered = total.linesCovered
+	document.LinesValid = total.linesValid
+	document.BranchesCovered = total.branchesCovered
+	document.BranchesValid = total.branchesValid
+
+	if _, err := io.WriteString(writer, xml.Header+coberturaDocType+"\n"); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(writer)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(document); err != nil {
+		return err
+	}
+	_, err := io.WriteString(writer, "\n")
+	return err
+}
+
+func collectCoberturaPackages(packages *[]coberturaPackage, total *coberturaCounts, nodes []report.TreeNode, filesByAnchor map[string]*report.FileReport) {
+	for _, node := range nodes {
+		if !node.IsDir {
+			continue
+		}
+
+		files := make([]report.TreeNode, 0)
+		for _, child := range node.Children {
+			if !child.IsDir {
+				files = append(files, child)
+			}
+		}
+		if len(files) > 0 {
+			pkg, counts := newCoberturaPackage(node.Path, files, filesByAnchor)
+			*packages = append(*packages, pkg)
+			total.add(counts)
+		}
+
+		collectCoberturaPackages(packages, total, node.Children, filesByAnchor)
+	}
+}
+
+func newCoberturaPackage(name string, nodes []report.TreeNode, filesByAnchor map[string]*report.FileReport) (coberturaPackage, coberturaCounts) {
+	pkg := coberturaPackage{Name: name, Classes: make([]coberturaClass, 0, len(nodes))}
+	var counts coberturaCounts
+	for _, node := range nodes {
+		file := filesByAnchor[node.Anchor]
+		if file == nil {
+			continue
+		}
+		class, classCounts := newCoberturaClass(node.RelativePath, *file)
+		pkg.Classes = append(pkg.Classes, class)
+		counts.add(classCounts)
+	}
+	pkg.LineRate = counts.lineRate()
+	pkg.BranchRate = counts.branchRate()
+	return pkg, counts
+}
+
+func newCoberturaClass(fileName string, file report.FileReport) (coberturaClass, coberturaCounts) {
+	class := coberturaClass{
+		Name:     strings.TrimSuffix(fileName, path.Ext(fileName)),
+		FileName: fileName,
+		Methods:  make([]coberturaMethod, 0, len(file.Functions)),
+		Lines:    make([]coberturaLine, 0),
+	}
+
+	lines := file.Lines
+	if file.Missing {
+		lines = blockLines(file.Blocks)
+	}
+
+	var counts coberturaCounts
+	lineCounts := make(map[int]coberturaCounts)
+	for _, line := range lines {
+		entry, lineCount, ok := newCoberturaLine(file, line)
+		if !ok {
+			continue
+		}
+		class.Lines = append(class.Lines, entry)
+		lineCounts[line.Number] = lineCount
+		counts.add(lineCount)
+	}
+
+	for _, function := range file.Functions {
+		method := coberturaMethod{Name: function.DisplayName(), Lines: make([]coberturaLine, 0)}
+		var methodCounts coberturaCounts
+		for _, line := range class.Lines {
+			if line.Number >= function.StartLine && line.Number <= function.EndLine {
+				method.Lines = append(method.Lines, line)
+				methodCounts.add(lineCounts[line.Number])
+			}
+		}
+		method.LineRate = methodCounts.lineRate()
+		method.BranchRate = methodCounts.branchRate()
+		class.Methods = append(class.Methods, method)
+	}
+
+	class.LineRate = counts.lineRate()
+	class.BranchRate = counts.branchRate()
+	return class, counts
+}
+
+// blockLines derives the tracked lines of a file whose source was not found
+// from its blocks, classed like the lines of a file that was.
+func blockLines(blocks []report.BlockCoverage) []report.LineCoverage {
+	byNumber := make(map[int]*report.LineCoverage)
+	covered := make(map[int]bool)
+	missed := make(map[int]bool)
+	for _, block := range blocks {
+		if block.Ignored || block.NumStmt == 0 {
+			continue
+		}
+		for number := block.StartLine; number <= block.EndLine; number++ {
+			line := byNumber[number]
+			if line == nil {
+				line = &report.LineCoverage{Number: number}
+				byNumber[number] = line
+			}
+			line.SumHits += block.Hits
+			if block.Hits > line.MaxHits {
+				line.MaxHits = block.Hits
+			}
+			if block.Hits > 0 {
+				covered[number] = true
+			} else {
+				missed[number] = true
+			}
+		}
+	}
+
+	numbers := make([]int, 0, len(byNumber))
+	for number := range byNumber {
+		numbers = append(numbers, number)
+	}
+	sort.Ints(numbers)
+
+	lines := make([]report.LineCoverage, 0, len(numbers))
+	for _, number := range numbers {
+		line := *byNumber[number]
+		switch {
+		case covered[number] && missed[number]:
+			line.Class = "partial"
+		case covered[number]:
+			line.Class = "covered"
+		default:
+			line.Class = "missed"
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// newCoberturaLine converts a line with statements. It reports false for
+// lines without statements and for ignored lines.
+func newCoberturaLine(file report.FileReport, line report.LineCoverage) (coberturaLine, coberturaCounts, bool) {
+	var counts coberturaCounts
+	switch line.Class {
+	case "covered", "partial":
+		counts.linesCovered = 1
+	case "missed":
+	default:
+		return coberturaLine{}, counts, false
+	}
+	counts.linesValid = 1
+
+	entry := coberturaLine{Number: line.Number, Hits: line.MaxHits}
+	covered, total := lineBranches(file, line)
+	if total > 0 {
+		entry.Branch = true
+		entry.ConditionCoverage = fmt.Sprintf("%d%% (%d/%d)", covered*100/total, covered, total)
+		counts.branchesCovered = covered
+		counts.branchesValid = total
+	}
+	return entry, counts, true
+}
+
+// lineBranches returns the taken and total branches of line. Lines from LCOV
+// and Cobertura inputs carry their own branch data; for Go profiles, the
+// blocks on a partial line are its branches.
+func lineBranches(file report.FileReport, line report.LineCoverage) (int, int) {
+	if len(line.Branches) > 0 {
+		taken := 0
+		for _, branch := range line.Branches {
+			if branch.Taken > 0 {
+				taken++
+			}
+		}
+		return taken, len(line.Branches)
+	}
+
+	if line.Class != "partial" {
+		return 0, 0
+	}
+	covered, total := 0, 0
+	for _, block := range file.Blocks {
+		if block.Ignored || block.NumStmt == 0 || line.Number < block.StartLine || line.Number > block.EndLine {
+			continue
+		}
+		total++
+		if block.Hits > 0 {
+			covered++
+		}
+	}
+	return covered, total
+}
+
+// coberturaRate formats covered/valid with four decimals. Nothing to cover
+// counts as fully covered, as in the HTML report.
+func coberturaRate(covered, valid int) string {
+	rate := 1.0
+	if valid > 0 {
+		rate = float64(covered) / float64(valid)
+	}
+	return strconv.FormatFloat(rate, 'f', 4, 64)
+}
+
+// coberturaTimestamp converts the report time to Unix milliseconds.
+func coberturaTimestamp(generatedAt string) int64 {
+	generated, err := time.ParseInLocation("2006-01-02 15:04:05", generatedAt, time.Local)
+	if err != nil {
+		generated = time.Now()
+	}
+	return generated.UnixMilli()
+}
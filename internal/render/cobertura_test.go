@@ -0,0 +1,54 @@
+package render
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"golang.org/x/tools/cover"
+
+	"github.com/beardnick/go-test-coverage/internal/report"
+)
+
+func TestCoberturaMissingSource(t *testing.T) {
+	root := t.TempDir()
+
+	profiles := []*cover.Profile{{
+		FileName: "gone/gone.go",
+		Mode:     "set",
+		Blocks: []cover.ProfileBlock{
+			{StartLine: 3, StartCol: 14, EndLine: 5, EndCol: 2, NumStmt: 1, Count: 1},
+			{StartLine: 7, StartCol: 14, EndLine: 7, EndCol: 30, NumStmt: 1, Count: 0},
+		},
+	}}
+	reportData, err := report.GenerateFromProfiles(profiles, report.Options{Root: root})
+	if err != nil {
+		t.Fatalf("GenerateFromProfiles() error = %v", err)
+	}
+	if !reportData.Files[0].Missing {
+		t.Fatalf("file is not missing")
+	}
+
+	var output bytes.Buffer
+	if err := Cobertura(&output, reportData); err != nil {
+		t.Fatalf("Cobertura() error = %v", err)
+	}
+	var document coberturaCoverage
+	if err := xml.Unmarshal(output.Bytes(), &document); err != nil {
+		t.Fatalf("invalid XML: %v", err)
+	}
+
+	if document.LineRate != "0.7500" || document.LinesCovered != 3 || document.LinesValid != 4 {
+		t.Errorf("coverage line-rate=%s lines-covered=%d lines-valid=%d, want 0.7500, 3, 4", document.LineRate, document.LinesCovered, document.LinesValid)
+	}
+	if len(document.Packages) != 1 || len(document.Packages[0].Classes) != 1 {
+		t.Fatalf("packages = %+v, want one class", document.Packages)
+	}
+	class := document.Packages[0].Classes[0]
+	if class.FileName != "gone/gone.go" {
+		t.Errorf("class filename = %q, want the name in the coverage data", class.FileName)
+	}
+	if len(class.Lines) != 4 || class.Lines[0].Number != 3 || class.Lines[3].Number != 7 || class.Lines[3].Hits != 0 {
+		t.Errorf("class lines = %+v, want lines 3-5 and 7", class.Lines)
+	}
+}
@@ -0,0 +1,286 @@
+package render
+
+import (
+	"go/scanner"
+	"go/token"
+	"html/template"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/beardnick/go-test-coverage/internal/report"
+)
+
+// goIdentClasses are the predeclared identifiers highlighted like the Go
+// grammar of highlight.js does, whose github themes style the classes.
+var goIdentClasses = map[string]string{
+	"true": "hljs-literal", "false": "hljs-literal", "iota": "hljs-literal", "nil": "hljs-literal",
+
+	"any": "hljs-type", "bool": "hljs-type", "byte": "hljs-type", "comparable": "hljs-type",
+	"complex64": "hljs-type", "complex128": "hljs-type", "error": "hljs-type",
+	"float32": "hljs-type", "float64": "hljs-type", "int": "hljs-type", "int8": "hljs-type",
+	"int16": "hljs-type", "int32": "hljs-type", "int64": "hljs-type", "rune": "hljs-type",
+	"string": "hljs-type", "uint": "hljs-type", "uint8": "hljs-type", "uint16": "hljs-type",
+	"uint32": "hljs-type", "uint64": "hljs-type", "uintptr": "hljs-type",
+
+	"append": "hljs-built_in", "cap": "hljs-built_in", "clear": "hljs-built_in",
+	"close": "hljs-built_in", "complex": "hljs-built_in", "copy": "hljs-built_in",
+	"delete": "hljs-built_in", "imag": "hljs-built_in", "len": "hljs-built_in",
+	"make": "hljs-built_in", "max": "hljs-built_in", "min": "hljs-built_in",
+	"new": "hljs-built_in", "panic": "hljs-built_in", "print": "hljs-built_in",
+	"println": "hljs-built_in", "real": "hljs-built_in", "recover": "hljs-built_in",
+}
+
+const goFunctionClass = "hljs-title function_"
+
+// highlightedLine is a source line as HTML, with syntax highlighting and the
+// missed columns of partial lines marked.
+type highlightedLine struct {
+	report.LineCoverage
+	HTML template.HTML
+}
+
+// classSpan is a highlighted range of source bytes.
+type classSpan struct {
+	start int
+	end   int
+	class string
+}
+
+// highlightLines renders the lines of file as HTML. Go files are tokenized
+// as a whole, so that raw strings and block comments spanning lines are
+// highlighted correctly; their spans are closed and reopened on every line,
+// so each line is valid HTML on its own.
+func highlightLines(file report.FileReport) []highlightedLine {
+	codes := make([]string, len(file.Lines))
+	for index, line := range file.Lines {
+		codes[index] = line.Code
+	}
+	source := strings.Join(codes, "\n")
+
+	var spans []classSpan
+	if strings.HasSuffix(file.Name, ".go") {
+		spans = goTokenSpans(source)
+	}
+
+	lines := make([]highlightedLine, len(file.Lines))
+	lineStart := 0
+	for index, line := range file.Lines {
+		lineEnd := lineStart + len(line.Code)
+
+		// Spans are sorted and do not overlap, so the spans of the line
+		// start with the first one ending after the line start.
+		first := sort.Search(len(spans), func(i int) bool { return spans[i].end > lineStart })
+		var lineSpans []classSpan
+		for _, span := range spans[first:] {
+			if span.start >= lineEnd {
+				break
+			}
+			lineSpans = append(lineSpans, classSpan{
+				start: clampOffset(span.start-lineStart, len(line.Code)),
+				end:   clampOffset(span.end-lineStart, len(line.Code)),
+				class: span.class,
+			})
+		}
+
+		lines[index] = highlightedLine{
+			LineCoverage: line,
+			HTML:         highlightLine(line.Code, lineSpans, line.Missed),
+		}
+		lineStart = lineEnd + 1
+	}
+	return lines
+}
+
+// highlightLine writes code with the class spans, wrapping the missed
+// columns in partial-range spans. Class spans are split at the range
+// boundaries, so the markup nests.
+func highlightLine(code string, spans []classSpan, missed []report.ColumnRange) template.HTML {
+	ranges := make([]classSpan, 0, len(missed))
+	for _, column := range missed {
+		start := runeStart(code, column.Start-1)
+		end := runeStart(code, column.End-1)
+		if end > start {
+			ranges = append(ranges, classSpan{start: start, end: end})
+		}
+	}
+
+	boundaries := []int{0, len(code)}
+	for _, span := range spans {
+		boundaries = append(boundaries, span.start, span.end)
+	}
+	for _, span := range ranges {
+		boundaries = append(boundaries, span.start, span.end)
+	}
+	sort.Ints(boundaries)
+
+	var html strings.Builder
+	inRange := false
+	spanIndex := 0
+	for index := 1; index < len(boundaries); index++ {
+		start, end := boundaries[index-1], boundaries[index]
+		if end <= start {
+			continue
+		}
+
+		if covered := containsOffset(ranges, start); covered != inRange {
+			if covered {
+				html.WriteString(`<span class="partial-range">`)
+			} else {
+				html.WriteString("</span>")
+			}
+			inRange = covered
+		}
+
+		for spanIndex < len(spans) && spans[spanIndex].end <= start {
+			spanIndex++
+		}
+		text := template.HTMLEscapeString(code[start:end])
+		if spanIndex < len(spans) && spans[spanIndex].start <= start {
+			html.WriteString(`<span class="` + spans[spanIndex].class + `">` + text + "</span>")
+		} else {
+			html.WriteString(text)
+		}
+	}
+	if inRange {
+		html.WriteString("</span>")
+	}
+
+	return template.HTML(html.String())
+}
+
+// goTokenSpans scans source as Go and returns the spans of the highlighted
+// tokens in order. Scan errors are ignored, so that invalid code is still
+// highlighted as far as possible.
+func goTokenSpans(source string) []classSpan {
+	fileSet := token.NewFileSet()
+	file := fileSet.AddFile("", fileSet.Base(), len(source))
+
+	var goScanner scanner.Scanner
+	goScanner.Init(file, []byte(source), func(token.Position, string) {}, scanner.ScanComments)
+
+	// Function declarations start at the beginning of a line; their name
+	// follows func and the optional receiver.
+	const (
+		declNone = iota
+		declReceiverOrName
+		declReceiver
+		declName
+	)
+	decl := declNone
+	parens := 0
+
+	var spans []classSpan
+	for {
+		pos, tok, lit := goScanner.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok == token.SEMICOLON && lit == "\n" {
+			// Inserted automatically, not in the source.
+			continue
+		}
+		start := file.Offset(pos)
+		end := tokenEnd(source, start, tok, lit)
+
+		class := ""
+		switch {
+		case tok == token.COMMENT:
+			class = "hljs-comment"
+		case tok == token.STRING || tok == token.CHAR:
+			class = "hljs-string"
+		case tok == token.INT || tok == token.FLOAT || tok == token.IMAG:
+			class = "hljs-number"
+		case tok.IsKeyword():
+			class = "hljs-keyword"
+		case tok == token.IDENT && (decl == declReceiverOrName || decl == declName):
+			class = goFunctionClass
+		case tok == token.IDENT:
+			class = goIdentClasses[lit]
+		}
+
+		switch {
+		case tok == token.FUNC:
+			decl = declNone
+			if start == 0 || source[start-1] == '\n' {
+				decl = declReceiverOrName
+			}
+		case tok == token.COMMENT:
+		case decl == declReceiverOrName && tok == token.LPAREN:
+			decl = declReceiver
+			parens = 1
+		case decl == declReceiver:
+			if tok == token.LPAREN {
+				parens++
+			} else if tok == token.RPAREN {
+				parens--
+				if parens == 0 {
+					decl = declName
+				}
+			}
+		default:
+			decl = declNone
+		}
+
+		if class != "" && end > start {
+			spans = append(spans, classSpan{start: start, end: end, class: class})
+		}
+	}
+	return spans
+}
+
+// tokenEnd returns the offset after the token at start. The scanner removes
+// carriage returns from raw strings and comments, so their end is looked up
+// in source rather than derived from lit.
+func tokenEnd(source string, start int, tok token.Token, lit string) int {
+	end := start + len(lit)
+	switch {
+	case tok == token.STRING && strings.HasPrefix(lit, "`"):
+		end = len(source)
+		if index := strings.IndexByte(source[start+1:], '`'); index >= 0 {
+			end = start + 1 + index + 1
+		}
+	case tok == token.COMMENT && strings.HasPrefix(lit, "/*"):
+		end = len(source)
+		if index := strings.Index(source[start+2:], "*/"); index >= 0 {
+			end = start + 2 + index + 2
+		}
+	case tok == token.COMMENT:
+		end = len(source)
+		if index := strings.IndexByte(source[start:], '\n'); index >= 0 {
+			end = start + index
+		}
+	case lit == "":
+		end = start + len(tok.String())
+	}
+	return clampOffset(end, len(source))
+}
+
+func containsOffset(spans []classSpan, offset int) bool {
+	for _, span := range spans {
+		if span.start <= offset && offset < span.end {
+			return true
+		}
+	}
+	return false
+}
+
+func clampOffset(offset, length int) int {
+	if offset < 0 {
+		return 0
+	}
+	if offset > length {
+		return length
+	}
+	return offset
+}
+
+// runeStart clamps offset to code and moves it back to the start of the
+// character it falls into, so that markup never splits a character.
+func runeStart(code string, offset int) int {
+	offset = clampOffset(offset, len(code))
+	for offset > 0 && offset < len(code) && !utf8.RuneStart(code[offset]) {
+		offset--
+	}
+	return offset
+}
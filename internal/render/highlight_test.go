@@ -0,0 +1,184 @@
+package render
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/beardnick/go-test-coverage/internal/report"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files of the tests")
+
+// fileWithSource returns a report file with a line per line of source.
+func fileWithSource(name, source string) report.FileReport {
+	file := report.FileReport{Name: name}
+	for index, code := range strings.Split(source, "\n") {
+		file.Lines = append(file.Lines, report.LineCoverage{Number: index + 1, Code: code})
+	}
+	return file
+}
+
+func renderLines(lines []highlightedLine) string {
+	var builder strings.Builder
+	for _, line := range lines {
+		builder.WriteString(string(line.HTML))
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
+
+func TestHighlightLinesGolden(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "highlight", "*.go.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) == 0 {
+		t.Fatal("no golden inputs")
+	}
+
+	for _, input := range inputs {
+		t.Run(filepath.Base(input), func(t *testing.T) {
+			source, err := os.ReadFile(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The inputs are not .go files so that gofmt skips the broken ones.
+			name := strings.TrimSuffix(filepath.Base(input), ".txt")
+			got := renderLines(highlightLines(fileWithSource(name, string(source))))
+
+			golden := strings.TrimSuffix(input, ".go.txt") + ".golden"
+			if *update {
+				if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(want) {
+				t.Errorf("highlightLines(%s) differs from %s:\n%s", input, golden, got)
+			}
+
+			// Carriage returns stay in the lines but must not shift the
+			// tokens after them.
+			crlf := strings.ReplaceAll(string(source), "\n", "\r\n")
+			gotCRLF := renderLines(highlightLines(fileWithSource(name, crlf)))
+			if stripped := strings.ReplaceAll(gotCRLF, "\r", ""); stripped != string(want) {
+				t.Errorf("highlightLines(%s) with CRLF line endings differs from %s:\n%s", input, golden, stripped)
+			}
+		})
+	}
+}
+
+func TestHighlightLinesOnlyGoFiles(t *testing.T) {
+	lines := highlightLines(fileWithSource("notes.txt", "func main() { return }"))
+	if got, want := string(lines[0].HTML), "func main() { return }"; got != want {
+		t.Errorf("highlightLines(notes.txt) = %q, want %q", got, want)
+	}
+}
+
+func TestHighlightLine(t *testing.T) {
+	tests := []struct {
+		name   string
+		code   string
+		missed []report.ColumnRange
+		want   string
+	}{
+		{
+			name: "no ranges",
+			code: `return "a<b"`,
+			want: `<span class="hljs-keyword">return</span> <span class="hljs-string">&#34;a&lt;b&#34;</span>`,
+		},
+		{
+			name:   "range inside a token",
+			code:   `x := "abcdef"`,
+			missed: []report.ColumnRange{{Start: 9, End: 12}},
+			want: `x := <span class="hljs-string">&#34;ab</span>` +
+				`<span class="partial-range"><span class="hljs-string">cde</span></span>` +
+				`<span class="hljs-string">f&#34;</span>`,
+		},
+		{
+			name:   "range across tokens",
+			code:   `if ok { return 1 }`,
+			missed: []report.ColumnRange{{Start: 7, End: 19}},
+			want: `<span class="hljs-keyword">if</span> ok ` +
+				`<span class="partial-range">{ <span class="hljs-keyword">return</span> <span class="hljs-number">1</span> }</span>`,
+		},
+		{
+			name:   "adjacent ranges",
+			code:   `a(); b()`,
+			missed: []report.ColumnRange{{Start: 1, End: 4}, {Start: 4, End: 9}},
+			want:   `<span class="partial-range">a(); b()</span>`,
+		},
+		{
+			name:   "range starting inside a multi-byte rune",
+			code:   `s := "héllo"`,
+			missed: []report.ColumnRange{{Start: 9, End: 11}},
+			want: `s := <span class="hljs-string">&#34;h</span>` +
+				`<span class="partial-range"><span class="hljs-string">él</span></span>` +
+				`<span class="hljs-string">lo&#34;</span>`,
+		},
+		{
+			name:   "range ending inside a multi-byte rune",
+			code:   `s := "世界"`,
+			missed: []report.ColumnRange{{Start: 7, End: 11}},
+			want: `s := <span class="hljs-string">&#34;</span>` +
+				`<span class="partial-range"><span class="hljs-string">世</span></span>` +
+				`<span class="hljs-string">界&#34;</span>`,
+		},
+		{
+			name:   "range past the end of the line",
+			code:   `go f()`,
+			missed: []report.ColumnRange{{Start: 4, End: 40}},
+			want:   `<span class="hljs-keyword">go</span> <span class="partial-range">f()</span>`,
+		},
+		{
+			name:   "empty range",
+			code:   `go f()`,
+			missed: []report.ColumnRange{{Start: 4, End: 4}},
+			want:   `<span class="hljs-keyword">go</span> f()`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := string(highlightLine(test.code, goTokenSpans(test.code), test.missed))
+			if got != test.want {
+				t.Errorf("highlightLine(%q) =\n%s\nwant\n%s", test.code, got, test.want)
+			}
+		})
+	}
+}
+
+func TestGoTokenSpans(t *testing.T) {
+	source := "func (r *T) M() {}\n/* a\r\nb */ x := `c\r\nd` // e\r\nf"
+	type span struct {
+		text  string
+		class string
+	}
+	want := []span{
+		{"func", "hljs-keyword"},
+		{"M", goFunctionClass},
+		{"/* a\r\nb */", "hljs-comment"},
+		{"`c\r\nd`", "hljs-string"},
+		{"// e\r", "hljs-comment"},
+	}
+
+	spans := goTokenSpans(source)
+	got := make([]span, 0, len(spans))
+	for _, item := range spans {
+		got = append(got, span{text: source[item.start:item.end], class: item.class})
+	}
+	if len(got) != len(want) {
+		t.Fatalf("goTokenSpans() = %q, want %q", got, want)
+	}
+	for index := range want {
+		if got[index] != want[index] {
+			t.Errorf("span %d = %q, want %q", index, got[index], want[index])
+		}
+	}
+}
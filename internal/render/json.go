@@ -0,0 +1,295 @@
+package render
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+
+	"github.com/beardnick/go-test-coverage/internal/report"
+)
+
+// JSONSchemaVersion is the version of the JSON report layout. It is
+// incremented whenever a field is removed or changes its meaning; new fields
+// may be added within a version.
+const JSONSchemaVersion = 1
+
+// JSONOptions controls the optional parts of the JSON report.
+type JSONOptions struct {
+	// Lines adds the class, hit count and missed columns of every tracked
+	// or ignored line to each file.
+	Lines bool
+}
+
+type jsonReport struct {
+	SchemaVersion int             `json:"schemaVersion"`
+	Title         string          `json:"title"`
+	GeneratedAt   string          `json:"generatedAt"`
+	Mode          string          `json:"mode,omitempty"`
+	Total         jsonCoverage    `json:"total"`
+	FileCount     int             `json:"fileCount"`
+	MissingFiles  int             `json:"missingFiles"`
+	UntestedFiles int             `json:"untestedFiles"`
+	Bands         []jsonBand      `json:"bands"`
+	Thresholds    *jsonThresholds `json:"thresholds,omitempty"`
+	Patch         *jsonPatch      `json:"patch,omitempty"`
+	Tree          []jsonNode      `json:"tree"`
+	Files         []jsonFile      `json:"files"`
+	Excluded      []jsonExcluded  `json:"excluded"`
+}
+
+type jsonCoverage struct {
+	CoveredStatements int        `json:"coveredStatements"`
+	TotalStatements   int        `json:"totalStatements"`
+	IgnoredStatements int        `json:"ignoredStatements,omitempty"`
+	Percent           float64    `json:"percent"`
+	Class             string     `json:"class"`
+	Delta             *jsonDelta `json:"delta,omitempty"`
+}
+
+type jsonDelta struct {
+	Status            string  `json:"status,omitempty"`
+	Percent           float64 `json:"percent"`
+	CoveredStatements int     `json:"coveredStatements"`
+	TotalStatements   int     `json:"totalStatements"`
+}
+
+type jsonBand struct {
+	Name  string  `json:"name"`
+	Min   float64 `json:"min"`
+	Color string  `json:"color"`
+}
+
+type jsonThresholds struct {
+	Total   float64 `json:"total"`
+	Package float64 `json:"package"`
+	File    float64 `json:"file"`
+	Failed  bool    `json:"failed"`
+}
+
+type jsonPatch struct {
+	Base         string          `json:"base"`
+	CoveredLines int             `json:"coveredLines"`
+	TotalLines   int             `json:"totalLines"`
+	Percent      float64         `json:"percent"`
+	Class        string          `json:"class"`
+	Files        []jsonPatchFile `json:"files"`
+}
+
+type jsonPatchFile struct {
+	Name           string  `json:"name"`
+	CoveredLines   int     `json:"coveredLines"`
+	TotalLines     int     `json:"totalLines"`
+	Percent        float64 `json:"percent"`
+	UncoveredLines string  `json:"uncoveredLines"`
+}
+
+type jsonNode struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	Dir  bool   `json:"dir"`
+	jsonCoverage
+	Untested       bool       `json:"untested,omitempty"`
+	BelowThreshold bool       `json:"belowThreshold,omitempty"`
+	Children       []jsonNode `json:"children,omitempty"`
+}
+
+type jsonFile struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	jsonCoverage
+	Missing        bool           `json:"missing,omitempty"`
+	Untested       bool           `json:"untested,omitempty"`
+	Generated      bool           `json:"generated,omitempty"`
+	BelowThreshold bool           `json:"belowThreshold,omitempty"`
+	Functions      []jsonFunction `json:"functions"`
+	Lines          []jsonLine     `json:"lines,omitempty"`
+}
+
+type jsonFunction struct {
+	Name      string `json:"name"`
+	Receiver  string `json:"receiver,omitempty"`
+	StartLine int    `json:"startLine"`
+	EndLine   int    `json:"endLine"`
+	jsonCoverage
+}
+
+type jsonLine struct {
+	Number   int          `json:"number"`
+	Class    string       `json:"class"`
+	Hits     int          `json:"hits"`
+	Missed   [][2]int     `json:"missed,omitempty"`
+	Branches []jsonBranch `json:"branches,omitempty"`
+}
+
+type jsonBranch struct {
+	Block  int    `json:"block"`
+	Branch string `json:"branch"`
+	Taken  int    `json:"taken"`
+}
+
+type jsonExcluded struct {
+	Name   string `json:"name"`
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// JSON writes reportData as an indented JSON document described by
+// JSONSchemaVersion.
+func JSON(writer io.Writer, reportData report.Report, options JSONOptions) error {
+	document := jsonReport{
+		SchemaVersion: JSONSchemaVersion,
+		Title:         reportData.Title,
+		GeneratedAt:   reportData.GeneratedAt,
+		Mode:          reportData.Mode,
+		Total:         newJSONCoverage(reportData.CoveredStmts, reportData.TotalStmts, reportData.Percent(), reportData.TotalCoverageClass, nil),
+		FileCount:     reportData.TotalFiles,
+		MissingFiles:  reportData.MissingFiles,
+		UntestedFiles: reportData.UntestedFiles,
+		Bands:         make([]jsonBand, 0, len(reportData.Bands)),
+		Tree:          newJSONNodes(reportData.Tree),
+		Files:         make([]jsonFile, 0, len(reportData.Files)),
+		Excluded:      make([]jsonExcluded, 0, len(reportData.Excluded)),
+	}
+	document.Total.IgnoredStatements = reportData.IgnoredStmts
+	if reportData.Comparison != nil {
+		document.Total.Delta = newJSONDelta(&reportData.Comparison.Total)
+	}
+
+	for _, band := range reportData.Bands {
+		document.Bands = append(document.Bands, jsonBand{Name: band.Name, Min: band.Min, Color: band.Color})
+	}
+
+	if reportData.Thresholds.Enabled() {
+		document.Thresholds = &jsonThresholds{
+			Total:   reportData.Thresholds.Total,
+			Package: reportData.Thresholds.Package,
+			File:    reportData.Thresholds.File,
+			Failed:  reportData.BelowThreshold || anyBelowThreshold(reportData.Tree),
+		}
+	}
+
+	if patch := reportData.Patch; patch != nil {
+		document.Patch = &jsonPatch{
+			Base:         patch.Base,
+			CoveredLines: patch.CoveredLines,
+			TotalLines:   patch.TotalLines,
+			Percent:      report.RoundPercent(patch.Percent()),
+			Class:        patch.CoverageClass,
+			Files:        make([]jsonPatchFile, 0, len(patch.Files)),
+		}
+		for _, file := range patch.Files {
+			document.Patch.Files = append(document.Patch.Files, jsonPatchFile{
+				Name:           file.Name,
+				CoveredLines:   file.CoveredLines,
+				TotalLines:     file.TotalLines,
+				Percent:        report.RoundPercent(file.Percent()),
+				UncoveredLines: file.UncoveredLines,
+			})
+		}
+	}
+
+	for _, file := range reportData.Files {
+		document.Files = append(document.Files, newJSONFile(file, options))
+	}
+
+	for _, excluded := range reportData.Excluded {
+		document.Excluded = append(document.Excluded, jsonExcluded{
+			Name:   excluded.Name,
+			Path:   excluded.RelativePath,
+			Reason: excluded.Reason,
+		})
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(document)
+}
+
+func newJSONCoverage(covered, total int, percent float64, class string, delta *report.Delta) jsonCoverage {
+	return jsonCoverage{
+		CoveredStatements: covered,
+		TotalStatements:   total,
+		Percent:           report.RoundPercent(percent),
+		Class:             class,
+		Delta:             newJSONDelta(delta),
+	}
+}
+
+func newJSONDelta(delta *report.Delta) *jsonDelta {
+	if delta == nil {
+		return nil
+	}
+	return &jsonDelta{
+		Status:            delta.Status,
+		Percent:           report.RoundPercent(delta.Percent),
+		CoveredStatements: delta.CoveredStmts,
+		TotalStatements:   delta.TotalStmts,
+	}
+}
+
+func newJSONNodes(nodes []report.TreeNode) []jsonNode {
+	result := make([]jsonNode, 0, len(nodes))
+	for _, node := range nodes {
+		result = append(result, jsonNode{
+			Name:           node.Name,
+			Path:           node.Path,
+			Dir:            node.IsDir,
+			jsonCoverage:   newJSONCoverage(node.CoveredStmts, node.TotalStmts, node.Percent(), node.CoverageClass, node.Delta),
+			Untested:       node.Untested,
+			BelowThreshold: node.BelowThreshold,
+			Children:       newJSONNodes(node.Children),
+		})
+	}
+	return result
+}
+
+func newJSONFile(file report.FileReport, options JSONOptions) jsonFile {
+	result := jsonFile{
+		Name:           file.Name,
+		Path:           filepath.ToSlash(file.RelativeSourcePath),
+		jsonCoverage:   newJSONCoverage(file.CoveredStmts, file.TotalStmts, file.Percent(), file.CoverageClass, file.Delta),
+		Missing:        file.Missing,
+		Untested:       file.Untested,
+		Generated:      file.Generated,
+		BelowThreshold: file.BelowThreshold,
+		Functions:      make([]jsonFunction, 0, len(file.Functions)),
+	}
+	result.IgnoredStatements = file.IgnoredStmts
+
+	for _, function := range file.Functions {
+		result.Functions = append(result.Functions, jsonFunction{
+			Name:         function.Name,
+			Receiver:     function.Receiver,
+			StartLine:    function.StartLine,
+			EndLine:      function.EndLine,
+			jsonCoverage: newJSONCoverage(function.CoveredStmts, function.TotalStmts, function.Percent(), function.CoverageClass, nil),
+		})
+	}
+
+	if !options.Lines {
+		return result
+	}
+	for _, line := range file.Lines {
+		if line.Class == "not-tracked" {
+			continue
+		}
+		entry := jsonLine{Number: line.Number, Class: line.Class, Hits: line.MaxHits}
+		for _, missed := range line.Missed {
+			entry.Missed = append(entry.Missed, [2]int{missed.Start, missed.End})
+		}
+		for _, branch := range line.Branches {
+			entry.Branches = append(entry.Branches, jsonBranch{Block: branch.Block, Branch: branch.Branch, Taken: branch.Taken})
+		}
+		result.Lines = append(result.Lines, entry)
+	}
+	return result
+}
+
+func anyBelowThreshold(nodes []report.TreeNode) bool {
+	for _, node := range nodes {
+		if node.BelowThreshold || anyBelowThreshold(node.Children) {
+			return true
+		}
+	}
+	return false
+}
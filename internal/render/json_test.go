@@ -0,0 +1,55 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"golang.org/x/tools/cover"
+
+	"github.com/beardnick/go-test-coverage/internal/report"
+)
+
+func TestJSONRoundsPercentages(t *testing.T) {
+	profiles := []*cover.Profile{{
+		FileName: "a/a.go",
+		Mode:     "set",
+		Blocks: []cover.ProfileBlock{
+			{StartLine: 1, StartCol: 1, EndLine: 1, EndCol: 2, NumStmt: 6, Count: 1},
+			{StartLine: 2, StartCol: 1, EndLine: 2, EndCol: 2, NumStmt: 1, Count: 0},
+		},
+	}}
+	reportData, err := report.GenerateFromProfiles(profiles, report.Options{Root: t.TempDir()})
+	if err != nil {
+		t.Fatalf("GenerateFromProfiles() error = %v", err)
+	}
+
+	var output bytes.Buffer
+	if err := JSON(&output, reportData, JSONOptions{}); err != nil {
+		t.Fatalf("JSON() error = %v", err)
+	}
+	var document struct {
+		Total struct {
+			Percent json.Number `json:"percent"`
+		} `json:"total"`
+		Tree []struct {
+			Percent json.Number `json:"percent"`
+		} `json:"tree"`
+		Files []struct {
+			Percent json.Number `json:"percent"`
+		} `json:"files"`
+	}
+	decoder := json.NewDecoder(&output)
+	decoder.UseNumber()
+	if err := decoder.Decode(&document); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+
+	// 6 of 7 statements, shown as 85.7% in the HTML report.
+	if document.Total.Percent != "85.7" || document.Tree[0].Percent != "85.7" || document.Files[0].Percent != "85.7" {
+		t.Errorf("percent total=%s tree=%s file=%s, want 85.7", document.Total.Percent, document.Tree[0].Percent, document.Files[0].Percent)
+	}
+	if reportData.TotalCoveragePercent != "85.7%" {
+		t.Errorf("TotalCoveragePercent = %q, want 85.7%%", reportData.TotalCoveragePercent)
+	}
+}
@@ -0,0 +1,90 @@
+package render
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"github.com/beardnick/go-test-coverage/internal/report"
+)
+
+// LCOV writes reportData as an LCOV tracefile for editor extensions such as
+// Coverage Gutters and for genhtml. Source files are named by their absolute
+// path. Every line with statements becomes a DA record, every function an FN
+// record and the branch data of LCOV and Cobertura inputs BRDA records. Files
+// whose source was not found get the lines of their blocks, as in Cobertura.
+func LCOV(writer io.Writer, reportData report.Report) error {
+	buffered := bufio.NewWriter(writer)
+	for _, file := range reportData.Files {
+		if file.Missing {
+			file.Lines = blockLines(file.Blocks)
+		}
+		writeLCOVRecord(buffered, file)
+	}
+	return buffered.Flush()
+}
+
+func writeLCOVRecord(writer *bufio.Writer, file report.FileReport) {
+	fmt.Fprintln(writer, "TN:")
+	fmt.Fprintf(writer, "SF:%s\n", file.SourcePath)
+
+	// The hit count of a function is the one of its first line with
+	// statements, which runs whenever the function is called.
+	functionsHit := 0
+	for _, function := range file.Functions {
+		fmt.Fprintf(writer, "FN:%d,%s\n", function.StartLine, function.DisplayName())
+	}
+	for _, function := range file.Functions {
+		hits := 0
+		for _, line := range file.Lines {
+			if line.Number >= function.StartLine && line.Number <= function.EndLine && isTracked(line) {
+				hits = line.MaxHits
+				break
+			}
+		}
+		if hits > 0 {
+			functionsHit++
+		}
+		fmt.Fprintf(writer, "FNDA:%d,%s\n", hits, function.DisplayName())
+	}
+	if len(file.Functions) > 0 {
+		fmt.Fprintf(writer, "FNF:%d\nFNH:%d\n", len(file.Functions), functionsHit)
+	}
+
+	branchesFound, branchesHit := 0, 0
+	for _, line := range file.Lines {
+		for _, branch := range line.Branches {
+			taken := "-"
+			if branch.Reached {
+				taken = fmt.Sprint(branch.Taken)
+			}
+			fmt.Fprintf(writer, "BRDA:%d,%d,%s,%s\n", line.Number, branch.Block, branch.Branch, taken)
+			branchesFound++
+			if branch.Taken > 0 {
+				branchesHit++
+			}
+		}
+	}
+	if branchesFound > 0 {
+		fmt.Fprintf(writer, "BRF:%d\nBRH:%d\n", branchesFound, branchesHit)
+	}
+
+	linesFound, linesHit := 0, 0
+	for _, line := range file.Lines {
+		if !isTracked(line) {
+			continue
+		}
+		fmt.Fprintf(writer, "DA:%d,%d\n", line.Number, line.MaxHits)
+		linesFound++
+		if line.MaxHits > 0 {
+			linesHit++
+		}
+	}
+	fmt.Fprintf(writer, "LF:%d\nLH:%d\n", linesFound, linesHit)
+	fmt.Fprintln(writer, "end_of_record")
+}
+
+// isTracked reports whether line holds statements that count for coverage.
+func isTracked(line report.LineCoverage) bool {
+	return line.Class == "covered" || line.Class == "partial" || line.Class == "missed"
+}
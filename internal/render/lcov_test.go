@@ -0,0 +1,51 @@
+package render
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"golang.org/x/tools/cover"
+
+	"github.com/beardnick/go-test-coverage/internal/report"
+)
+
+func TestLCOVMissingSource(t *testing.T) {
+	profiles := []*cover.Profile{{
+		FileName: "gone/gone.go",
+		Mode:     "set",
+		Blocks: []cover.ProfileBlock{
+			{StartLine: 3, StartCol: 14, EndLine: 5, EndCol: 2, NumStmt: 1, Count: 1},
+			{StartLine: 7, StartCol: 14, EndLine: 7, EndCol: 30, NumStmt: 1, Count: 0},
+		},
+	}}
+	reportData, err := report.GenerateFromProfiles(profiles, report.Options{Root: t.TempDir()})
+	if err != nil {
+		t.Fatalf("GenerateFromProfiles() error = %v", err)
+	}
+	if !reportData.Files[0].Missing {
+		t.Fatalf("file is not missing")
+	}
+
+	var output bytes.Buffer
+	if err := LCOV(&output, reportData); err != nil {
+		t.Fatalf("LCOV() error = %v", err)
+	}
+
+	want := "TN:\nSF:" + reportData.Files[0].SourcePath + "\nDA:3,1\nDA:4,1\nDA:5,1\nDA:7,0\nLF:4\nLH:3\nend_of_record\n"
+	if got := output.String(); got != want {
+		t.Errorf("LCOV() =\n%s\nwant\n%s", got, want)
+	}
+
+	var coberturaOutput bytes.Buffer
+	if err := Cobertura(&coberturaOutput, reportData); err != nil {
+		t.Fatalf("Cobertura() error = %v", err)
+	}
+	var document coberturaCoverage
+	if err := xml.Unmarshal(coberturaOutput.Bytes(), &document); err != nil {
+		t.Fatalf("invalid XML: %v", err)
+	}
+	if document.LinesValid != 4 || document.LinesCovered != 3 {
+		t.Errorf("Cobertura lines-valid=%d lines-covered=%d, want the LCOV totals 4, 3", document.LinesValid, document.LinesCovered)
+	}
+}
@@ -0,0 +1,217 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/beardnick/go-test-coverage/internal/report"
+)
+
+// GitHubCommentLimit is the maximum length of a GitHub comment in characters.
+const GitHubCommentLimit = 65536
+
+// MarkdownOptions controls the size of the Markdown summary.
+type MarkdownOptions struct {
+	// Depth is the deepest tree level listed in the package table, 1 being
+	// the top-level directories.
+	Depth int
+	// Files is the number of least covered files listed.
+	Files int
+	// MaxLength is the maximum length of the summary in characters. Table
+	// rows that do not fit are left out with a note.
+	MaxLength int
+}
+
+// DefaultMarkdownOptions fit a pull request comment.
+var DefaultMarkdownOptions = MarkdownOptions{Depth: 2, Files: 10, MaxLength: GitHubCommentLimit}
+
+// markdownReserve is kept free for the notes about omitted rows.
+const markdownReserve = 200
+
+// bandIndicators color coverage values from the highest band down.
+var bandIndicators = []string{"🟢", "🟡", "🟠", "🔴"}
+
+// Markdown writes a summary of reportData suited for a pull request comment:
+// the total, a table of the directories down to options.Depth and the least
+// covered files with their uncovered lines.
+func Markdown(writer io.Writer, reportData report.Report, options MarkdownOptions) error {
+	var header strings.Builder
+	indicator := bandIndicator(reportData.Bands, reportData.TotalCoverageClass)
+	fmt.Fprintf(&header, "## %s\n\n", escapeMarkdown(reportData.Title))
+	fmt.Fprintf(&header, "**Total coverage: %s %s** (%d / %d statements", indicator, reportData.TotalCoveragePercent, reportData.CoveredStmts, reportData.TotalStmts)
+	if comparison := reportData.Comparison; comparison != nil {
+		fmt.Fprintf(&header, ", %s since base", comparison.Total.PercentText())
+	}
+	header.WriteString(")\n")
+
+	if patch := reportData.Patch; patch != nil {
+		fmt.Fprintf(&header, "\n**Patch coverage: %s %s** (%d / %d changed lines)\n", bandIndicator(reportData.Bands, patch.CoverageClass), patch.CoveragePercent, patch.CoveredLines, patch.TotalLines)
+	}
+	if reportData.BelowThreshold || anyBelowThreshold(reportData.Tree) {
+		header.WriteString("\n❌ Coverage is below the configured minimum.\n")
+	}
+
+	packageRows := make([]string, 0)
+	collectPackageRows(&packageRows, reportData.Bands, reportData.Tree, 1, options.Depth)
+
+	files := leastCoveredFiles(reportData.Files, options.Files)
+	fileRows := make([]string, 0, len(files))
+	for _, file := range files {
+		fileRows = append(fileRows, fmt.Sprintf("| `%s` | %s %s | %d / %d | %s |\n",
+			escapeCode(filepath.ToSlash(file.RelativeSourcePath)), bandIndicator(reportData.Bands, file.CoverageClass), file.CoveragePercent, file.CoveredStmts, file.TotalStmts, file.UncoveredLines()))
+	}
+
+	const packageHeader = "\n| Package | Coverage | Statements |\n| --- | ---: | ---: |\n"
+	fileHeader := fmt.Sprintf("\n<details>\n<summary>Least covered files (%d)</summary>\n", len(fileRows))
+	const fileTable = "\n| File | Coverage | Statements | Uncovered lines |\n| --- | ---: | ---: | --- |\n"
+	const fileFooter = "\n</details>\n"
+
+	budget := options.MaxLength - markdownReserve - length(header.String())
+	if len(packageRows) > 0 {
+		budget -= length(packageHeader)
+	}
+	if len(fileRows) > 0 {
+		budget -= length(fileHeader) + length(fileTable) + length(fileFooter)
+	}
+	packageRows, packagesOmitted := fitRows(packageRows, &budget)
+	fileRows, filesOmitted := fitRows(fileRows, &budget)
+
+	var output strings.Builder
+	output.WriteString(header.String())
+	if len(packageRows) > 0 || packagesOmitted > 0 {
+		if len(packageRows) > 0 {
+			output.WriteString(packageHeader)
+		}
+		for _, row := range packageRows {
+			output.WriteString(row)
+		}
+		if packagesOmitted > 0 {
+			fmt.Fprintf(&output, "\n_%d more packages not shown._\n", packagesOmitted)
+		}
+	}
+	if len(fileRows) > 0 || filesOmitted > 0 {
+		output.WriteString(fileHeader)
+		if len(fileRows) > 0 {
+			output.WriteString(fileTable)
+		}
+		for _, row := range fileRows {
+			output.WriteString(row)
+		}
+		if filesOmitted > 0 {
+			fmt.Fprintf(&output, "\n_%d more files not shown._\n", filesOmitted)
+		}
+		output.WriteString(fileFooter)
+	}
+
+	_, err := io.WriteString(writer, output.String())
+	return err
+}
+
+func collectPackageRows(rows *[]string, bands []report.Band, nodes []report.TreeNode, depth, maxDepth int) {
+	if depth > maxDepth {
+		return
+	}
+	for _, node := range nodes {
+		if !node.IsDir {
+			continue
+		}
+		*rows = append(*rows, fmt.Sprintf("| `%s` | %s %s | %d / %d |\n",
+			escapeCode(node.Path), bandIndicator(bands, node.CoverageClass), node.CoveragePercent, node.CoveredStmts, node.TotalStmts))
+		collectPackageRows(rows, bands, node.Children, depth+1, maxDepth)
+	}
+}
+
+// leastCoveredFiles returns up to limit files that are not fully covered,
+// lowest coverage first and most uncovered statements first among equals.
+func leastCoveredFiles(files []report.FileReport, limit int) []report.FileReport {
+	candidates := make([]report.FileReport, 0)
+	for _, file := range files {
+		if file.TotalStmts > 0 && file.CoveredStmts < file.TotalStmts {
+			candidates = append(candidates, file)
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		left, right := candidates[i].Percent(), candidates[j].Percent()
+		if left != right {
+			return left < right
+		}
+		return candidates[i].TotalStmts-candidates[i].CoveredStmts > candidates[j].TotalStmts-candidates[j].CoveredStmts
+	})
+
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	return candidates
+}
+
+// fitRows keeps the leading rows that fit in budget and returns how many
+// were left out.
+func fitRows(rows []string, budget *int) ([]string, int) {
+	for index, row := range rows {
+		size := length(row)
+		if size > *budget {
+			return rows[:index], len(rows) - index
+		}
+		*budget -= size
+	}
+	return rows, 0
+}
+
+// bandIndicator returns a colored circle for the band name, from green for
+// the highest band to red, with white for the lowest of four or more bands.
+func bandIndicator(bands []report.Band, name string) string {
+	level := bandLevel(bands, name)
+	if level < 0 {
+		return "⚪"
+	}
+	return bandIndicators[level]
+}
+
+// bandLevel places the band name on a scale of four levels from 0 for the
+// highest band to 3 for the lowest. The lowest of four or more bands, which
+// holds uncovered code, and unknown names return -1.
+func bandLevel(bands []report.Band, name string) int {
+	const levels = 4
+	count := len(bands)
+	index := -1
+	for position, band := range bands {
+		if band.Name == name {
+			index = position
+		}
+	}
+	if index < 0 {
+		return -1
+	}
+	if count >= levels {
+		if index == count-1 {
+			return -1
+		}
+		count--
+	}
+	if count == 1 {
+		return 0
+	}
+	return index * (levels - 1) / (count - 1)
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "|", `\|`, "*", `\*`, "_", `\_`, "`", "\\`", "<", "&lt;", ">", "&gt;", "[", `\[`, "]", `\]`,
+)
+
+func escapeMarkdown(value string) string {
+	return markdownEscaper.Replace(value)
+}
+
+// escapeCode prepares a value for a code span inside a table cell.
+func escapeCode(value string) string {
+	return strings.NewReplacer("`", "'", "|", `\|`).Replace(value)
+}
+
+func length(value string) int {
+	return utf8.RuneCountInString(value)
+}
@@ -0,0 +1,89 @@
+package render
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/beardnick/go-test-coverage/internal/report"
+)
+
+const (
+	siteAssetsDir = "assets"
+	siteFilesDir  = "files"
+	siteIndex     = "index.html"
+)
+
+// Site writes reportData as a static site to dir: index.html with the tree
+// and summary, one page per file under files/ and the shared styles and
+// scripts under assets/. Pages link each other relatively, so the site also
+// works when opened from disk. Unlike HTML, the browser only loads the
+// source of the file being viewed.
+func Site(dir string, reportData report.Report) error {
+	if err := CopyAssets(filepath.Join(dir, siteAssetsDir)); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Join(dir, siteFilesDir), 0o755); err != nil {
+		return fmt.Errorf("create site dir: %w", err)
+	}
+
+	tmpl, err := parseReportTemplate()
+	if err != nil {
+		return err
+	}
+
+	links := &fileLinks{Site: true, pages: sitePages(reportData.Files)}
+
+	index := newPageData(reportData, links)
+	index.Page = pageIndex
+	index.AssetBase = siteAssetsDir + "/"
+	if err := writeSitePage(filepath.Join(dir, siteIndex), tmpl, index); err != nil {
+		return err
+	}
+
+	for fileIndex := range reportData.Files {
+		file := &reportData.Files[fileIndex]
+		page := newPageData(reportData, links)
+		page.Page = pageFile
+		page.AssetBase = "../" + siteAssetsDir + "/"
+		page.File = file
+		if err := writeSitePage(filepath.Join(dir, siteFilesDir, links.pages[file.Anchor]), tmpl, page); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sitePages names the page of every file after its anchor, which is unique
+// per file. Names that differ only in case get a numeric suffix, so they do
+// not overwrite each other on case-insensitive file systems.
+func sitePages(files []report.FileReport) map[string]string {
+	pages := make(map[string]string, len(files))
+	used := make(map[string]bool, len(files))
+	for _, file := range files {
+		name := file.Anchor
+		for suffix := 2; used[strings.ToLower(name)]; suffix++ {
+			name = fmt.Sprintf("%s-%d", file.Anchor, suffix)
+		}
+		used[strings.ToLower(name)] = true
+		pages[file.Anchor] = name + ".html"
+	}
+	return pages
+}
+
+func writeSitePage(path string, tmpl *template.Template, data pageData) error {
+	output, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := tmpl.Execute(output, data); err != nil {
+		output.Close()
+		return err
+	}
+
+	return output.Close()
+}
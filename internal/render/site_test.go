@@ -0,0 +1,66 @@
+package render
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/cover"
+
+	"github.com/beardnick/go-test-coverage/internal/report"
+)
+
+func TestSiteWritesAPagePerFile(t *testing.T) {
+	root := t.TempDir()
+	// The names sanitize to the same anchor, or to ones that differ in case.
+	functions := map[string]string{
+		"a/b-c.go": "Dash",
+		"a/b/c.go": "Slash",
+		"a/B/c.go": "Upper",
+	}
+	profiles := make([]*cover.Profile, 0, len(functions))
+	for name, function := range functions {
+		source := "package a\n\nfunc " + function + "() {}\n"
+		sourcePath := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(sourcePath), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(sourcePath, []byte(source), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		profiles = append(profiles, &cover.Profile{
+			FileName: "./" + name,
+			Mode:     "set",
+			Blocks:   []cover.ProfileBlock{{StartLine: 3, StartCol: 14, EndLine: 3, EndCol: 16, NumStmt: 0, Count: 1}},
+		})
+	}
+
+	reportData, err := report.GenerateFromProfiles(profiles, report.Options{Root: root})
+	if err != nil {
+		t.Fatalf("GenerateFromProfiles() error = %v", err)
+	}
+
+	dir := t.TempDir()
+	if err := Site(dir, reportData); err != nil {
+		t.Fatalf("Site() error = %v", err)
+	}
+
+	pages := sitePages(reportData.Files)
+	seen := make(map[string]string)
+	for _, file := range reportData.Files {
+		page := strings.ToLower(pages[file.Anchor])
+		if other, ok := seen[page]; ok {
+			t.Fatalf("%s and %s share the page %s", other, file.Name, page)
+		}
+		seen[page] = file.Name
+
+		content, err := os.ReadFile(filepath.Join(dir, siteFilesDir, pages[file.Anchor]))
+		if err != nil {
+			t.Fatalf("page of %s: %v", file.Name, err)
+		}
+		if function := functions[strings.TrimPrefix(file.Name, "./")]; !strings.Contains(string(content), function) {
+			t.Errorf("page of %s does not show %s", file.Name, function)
+		}
+	}
+}
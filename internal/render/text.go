@@ -0,0 +1,180 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/beardnick/go-test-coverage/internal/report"
+)
+
+// Text sort orders.
+const (
+	SortName      = "name"
+	SortCoverage  = "coverage"
+	SortUncovered = "uncovered"
+)
+
+// TextOptions controls the terminal summary.
+type TextOptions struct {
+	// Color adds ANSI colors by coverage band.
+	Color bool
+	// Depth is the deepest tree level shown, 1 being the top level; 0 shows
+	// the whole tree.
+	Depth int
+	// Sort orders the entries of every directory by SortName, SortCoverage
+	// (lowest first) or SortUncovered (most uncovered statements first).
+	Sort string
+	// Uncovered lists the uncovered line ranges below every file.
+	Uncovered bool
+	// BarWidth is the number of characters of the coverage bar.
+	BarWidth int
+}
+
+// DefaultTextOptions show the whole tree in name order.
+var DefaultTextOptions = TextOptions{Sort: SortName, BarWidth: 20}
+
+// ParseSort validates a text sort order.
+func ParseSort(value string) (string, error) {
+	switch value {
+	case SortName, SortCoverage, SortUncovered:
+		return value, nil
+	default:
+		return "", fmt.Errorf("unknown sort order %q, expected %s, %s or %s", value, SortName, SortCoverage, SortUncovered)
+	}
+}
+
+// ansiColors color the band levels of bandLevel, from green to red.
+var ansiColors = []string{"\x1b[32m", "\x1b[33m", "\x1b[38;5;208m", "\x1b[31m"}
+
+const (
+	ansiDim   = "\x1b[2m"
+	ansiBold  = "\x1b[1m"
+	ansiReset = "\x1b[0m"
+)
+
+type textRow struct {
+	name      string
+	node      report.TreeNode
+	uncovered string
+}
+
+// Text writes a table of the report tree for the terminal: every directory
+// and file with its coverage, statements and a bar, followed by a total line
+// in the style of `go test -cover`.
+func Text(writer io.Writer, reportData report.Report, options TextOptions) error {
+	uncovered := make(map[string]string)
+	if options.Uncovered {
+		for _, file := range reportData.Files {
+			uncovered[file.Anchor] = file.UncoveredLines()
+		}
+	}
+
+	rows := make([]textRow, 0)
+	collectTextRows(&rows, reportData.Tree, 1, options, uncovered)
+
+	nameWidth := len("FILE")
+	for _, row := range rows {
+		if width := length(row.name); width > nameWidth {
+			nameWidth = width
+		}
+	}
+
+	var output strings.Builder
+	style := func(code, text string) string {
+		if !options.Color || code == "" || text == "" {
+			return text
+		}
+		return code + text + ansiReset
+	}
+
+	header := fmt.Sprintf("%-*s %8s %12s", nameWidth, "FILE", "COVERAGE", "STATEMENTS")
+	output.WriteString(style(ansiBold, header) + "\n")
+
+	for _, row := range rows {
+		color := bandColor(reportData.Bands, row.node.CoverageClass)
+		name := row.name + strings.Repeat(" ", nameWidth-length(row.name))
+		if row.node.IsDir {
+			name = style(ansiBold, name)
+		}
+		statements := fmt.Sprintf("%d/%d", row.node.CoveredStmts, row.node.TotalStmts)
+		fmt.Fprintf(&output, "%s %s %12s  %s\n",
+			name,
+			style(color, fmt.Sprintf("%8s", row.node.CoveragePercent)),
+			statements,
+			coverageBar(row.node.Percent(), options.BarWidth, color, style))
+		if row.uncovered != "" {
+			indent := strings.Repeat(" ", length(row.name)-length(strings.TrimLeft(row.name, " "))+2)
+			output.WriteString(style(ansiDim, indent+"uncovered: "+row.uncovered) + "\n")
+		}
+	}
+
+	output.WriteString("\n")
+	total := fmt.Sprintf("coverage: %s of statements", reportData.TotalCoveragePercent)
+	output.WriteString(style(bandColor(reportData.Bands, reportData.TotalCoverageClass), total))
+	fmt.Fprintf(&output, " (%d/%d)\n", reportData.CoveredStmts, reportData.TotalStmts)
+
+	_, err := io.WriteString(writer, output.String())
+	return err
+}
+
+func collectTextRows(rows *[]textRow, nodes []report.TreeNode, depth int, options TextOptions, uncovered map[string]string) {
+	if options.Depth > 0 && depth > options.Depth {
+		return
+	}
+
+	sorted := sortTextNodes(nodes, options.Sort)
+	indent := strings.Repeat("  ", depth-1)
+	for _, node := range sorted {
+		name := indent + node.Name
+		if node.IsDir {
+			name += "/"
+		}
+		*rows = append(*rows, textRow{name: name, node: node, uncovered: uncovered[node.Anchor]})
+		if node.IsDir {
+			collectTextRows(rows, node.Children, depth+1, options, uncovered)
+		}
+	}
+}
+
+// sortTextNodes returns nodes in the requested order, keeping directories
+// before files as in the tree.
+func sortTextNodes(nodes []report.TreeNode, order string) []report.TreeNode {
+	sorted := append([]report.TreeNode(nil), nodes...)
+	less := func(left, right report.TreeNode) bool { return false }
+	switch order {
+	case SortCoverage:
+		less = func(left, right report.TreeNode) bool { return left.Percent() < right.Percent() }
+	case SortUncovered:
+		less = func(left, right report.TreeNode) bool {
+			return left.TotalStmts-left.CoveredStmts > right.TotalStmts-right.CoveredStmts
+		}
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].IsDir != sorted[j].IsDir {
+			return sorted[i].IsDir
+		}
+		return less(sorted[i], sorted[j])
+	})
+	return sorted
+}
+
+// coverageBar draws percent as a bar of width characters.
+func coverageBar(percent float64, width int, color string, style func(string, string) string) string {
+	if width <= 0 {
+		return ""
+	}
+	filled := int(percent/100*float64(width) + 0.5)
+	return style(color, strings.Repeat("█", filled)) + style(ansiDim, strings.Repeat("░", width-filled))
+}
+
+// bandColor returns the ANSI color of the band name, dim for uncovered code.
+func bandColor(bands []report.Band, name string) string {
+	level := bandLevel(bands, name)
+	if level < 0 {
+		return ansiDim
+	}
+	return ansiColors[level]
+}
@@ -0,0 +1,150 @@
+package render
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html/template"
+
+	"github.com/beardnick/go-test-coverage/internal/report"
+)
+
+// virtualReport is the file data embedded in a virtual HTML report, from
+// which virtual.js renders the selected file. Lines are encoded as arrays
+// rather than objects since large reports embed millions of them; files and
+// functions are few enough to keep readable keys.
+type virtualReport struct {
+	HasHitCounts  bool          `json:"hasHitCounts"`
+	FileThreshold float64       `json:"fileThreshold"`
+	Files         []virtualFile `json:"files"`
+}
+
+type virtualFile struct {
+	Anchor             string            `json:"anchor"`
+	Name               string            `json:"name"`
+	Percent            string            `json:"percent"`
+	Class              string            `json:"class"`
+	Covered            int               `json:"covered"`
+	Total              int               `json:"total"`
+	Ignored            int               `json:"ignored,omitempty"`
+	Untested           bool              `json:"untested,omitempty"`
+	BelowThreshold     bool              `json:"belowThreshold,omitempty"`
+	Changed            bool              `json:"changed,omitempty"`
+	Delta              *virtualDelta     `json:"delta,omitempty"`
+	Missing            bool              `json:"missing,omitempty"`
+	MissingDescription string            `json:"missingDescription,omitempty"`
+	Functions          []virtualFunction `json:"functions"`
+	Lines              []virtualLine     `json:"lines"`
+}
+
+type virtualDelta struct {
+	Class string `json:"class"`
+	Text  string `json:"text"`
+	Title string `json:"title,omitempty"`
+}
+
+type virtualFunction struct {
+	Name    string `json:"name"`
+	Start   int    `json:"start"`
+	End     int    `json:"end"`
+	Covered int    `json:"covered"`
+	Total   int    `json:"total"`
+	Percent string `json:"percent"`
+	Class   string `json:"class"`
+}
+
+// virtualLine is encoded as the array [highlighted code, class, max hits,
+// total hits, heat, changed, branch summary].
+type virtualLine highlightedLine
+
+func (line virtualLine) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{
+		line.HTML,
+		line.Class,
+		line.MaxHits,
+		line.SumHits,
+		line.Heat,
+		line.Changed,
+		line.BranchSummary(),
+	})
+}
+
+// virtualData encodes the files of reportData as gzip compressed JSON in
+// base64, to be embedded in the page and decoded by virtual.js.
+func virtualData(reportData report.Report) (template.HTML, error) {
+	data := virtualReport{
+		HasHitCounts:  reportData.HasHitCounts(),
+		FileThreshold: reportData.Thresholds.File,
+		Files:         make([]virtualFile, 0, len(reportData.Files)),
+	}
+	for _, file := range reportData.Files {
+		data.Files = append(data.Files, newVirtualFile(file))
+	}
+
+	var compressed bytes.Buffer
+	encoder := base64.NewEncoder(base64.StdEncoding, &compressed)
+	writer := gzip.NewWriter(encoder)
+	if err := json.NewEncoder(writer).Encode(data); err != nil {
+		return "", fmt.Errorf("encode report data: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return "", fmt.Errorf("compress report data: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return "", fmt.Errorf("encode report data: %w", err)
+	}
+
+	// Base64 has no characters that need escaping in a script element.
+	return template.HTML(compressed.String()), nil
+}
+
+func newVirtualFile(file report.FileReport) virtualFile {
+	result := virtualFile{
+		Anchor:             file.Anchor,
+		Name:               file.Name,
+		Percent:            file.CoveragePercent,
+		Class:              file.CoverageClass,
+		Covered:            file.CoveredStmts,
+		Total:              file.TotalStmts,
+		Ignored:            file.IgnoredStmts,
+		Untested:           file.Untested,
+		BelowThreshold:     file.BelowThreshold,
+		Changed:            file.Changed,
+		Missing:            file.Missing,
+		MissingDescription: file.MissingDescription,
+		Functions:          make([]virtualFunction, 0, len(file.Functions)),
+		Lines:              make([]virtualLine, 0, len(file.Lines)),
+	}
+
+	if delta := file.Delta; delta != nil {
+		if delta.Status != "" {
+			result.Delta = &virtualDelta{Class: delta.Status, Text: delta.Status}
+		} else {
+			result.Delta = &virtualDelta{
+				Class: delta.Class(),
+				Text:  delta.PercentText(),
+				Title: "was " + delta.BasePercent + ", " + delta.StmtsText(),
+			}
+		}
+	}
+
+	for _, function := range file.Functions {
+		result.Functions = append(result.Functions, virtualFunction{
+			Name:    function.DisplayName(),
+			Start:   function.StartLine,
+			End:     function.EndLine,
+			Covered: function.CoveredStmts,
+			Total:   function.TotalStmts,
+			Percent: function.CoveragePercent,
+			Class:   function.CoverageClass,
+		})
+	}
+
+	for _, line := range highlightLines(file) {
+		result.Lines = append(result.Lines, virtualLine(line))
+	}
+
+	return result
+}
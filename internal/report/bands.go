@@ -0,0 +1,161 @@
+package report
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Band is a named coverage range starting at Min percent. Its Name is the
+// CoverageClass of every value in the range and Color is the CSS color the
+// report draws it with.
+type Band struct {
+	Name  string
+	Min   float64
+	Color string
+}
+
+// DefaultBands are used when Options.Bands is empty.
+var DefaultBands = []Band{
+	{Name: "high", Min: 90, Color: "var(--covered)"},
+	{Name: "medium", Min: 75, Color: "var(--partial)"},
+	{Name: "low", Min: 0.1, Color: "var(--missed)"},
+	{Name: "none", Min: 0, Color: "var(--muted)"},
+}
+
+var (
+	bandNamePattern  = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+	bandColorPattern = regexp.MustCompile(`^(#[0-9a-fA-F]{3,8}|[a-zA-Z]+|(rgb|rgba|hsl|hsla|var)\([a-zA-Z0-9\s.,%/-]*\))$`)
+)
+
+// reservedBandNames are taken by the report's own band- CSS classes, which a
+// band's .band-<name> rule would restyle.
+var reservedBandNames = map[string]bool{
+	"swatch": true,
+}
+
+// ParseBands parses a band list such as "high=80,medium=60:#d29922,low=0".
+// Each entry is name=min with an optional :color; the color of a default
+// band name may be omitted.
+func ParseBands(spec string) ([]Band, error) {
+	defaults := make(map[string]string, len(DefaultBands))
+	for _, band := range DefaultBands {
+		defaults[band.Name] = band.Color
+	}
+
+	bands := make([]Band, 0)
+	for _, entry := range splitBandList(spec) {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, value, found := strings.Cut(entry, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid band %q: expected name=min[:color]", entry)
+		}
+		value, color, _ := strings.Cut(value, ":")
+		minimum, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid band %q: %w", entry, err)
+		}
+		name = strings.TrimSpace(name)
+		if color == "" {
+			color = defaults[name]
+		}
+		bands = append(bands, Band{Name: name, Min: minimum, Color: strings.TrimSpace(color)})
+	}
+
+	return NormalizeBands(bands)
+}
+
+// splitBandList splits spec at the commas that are not inside the
+// parentheses of a color such as rgb(0, 128, 0).
+func splitBandList(spec string) []string {
+	entries := make([]string, 0)
+	depth := 0
+	start := 0
+	for index, char := range spec {
+		switch char {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				entries = append(entries, spec[start:index])
+				start = index + 1
+			}
+		}
+	}
+	return append(entries, spec[start:])
+}
+
+// NormalizeBands validates bands and sorts them from the highest minimum to
+// the lowest. Names must be unique lowercase identifiers other than the
+// reserved ones, minimums between 0 and 100 with one band starting at 0, and
+// colors plain CSS colors.
+func NormalizeBands(bands []Band) ([]Band, error) {
+	if len(bands) == 0 {
+		return nil, fmt.Errorf("no coverage bands")
+	}
+
+	sorted := make([]Band, len(bands))
+	copy(sorted, bands)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Min > sorted[j].Min
+	})
+
+	seen := make(map[string]bool, len(sorted))
+	for index, band := range sorted {
+		if !bandNamePattern.MatchString(band.Name) {
+			return nil, fmt.Errorf("invalid band name %q: use lowercase letters, digits and dashes", band.Name)
+		}
+		if reservedBandNames[band.Name] {
+			return nil, fmt.Errorf("invalid band name %q: reserved by the report", band.Name)
+		}
+		if seen[band.Name] {
+			return nil, fmt.Errorf("duplicate band %q", band.Name)
+		}
+		seen[band.Name] = true
+		if band.Min < 0 || band.Min > 100 {
+			return nil, fmt.Errorf("band %q: minimum %v is outside 0-100", band.Name, band.Min)
+		}
+		if index > 0 && band.Min == sorted[index-1].Min {
+			return nil, fmt.Errorf("bands %q and %q have the same minimum", sorted[index-1].Name, band.Name)
+		}
+		if band.Color == "" {
+			return nil, fmt.Errorf("band %q needs a color", band.Name)
+		}
+		if !bandColorPattern.MatchString(band.Color) {
+			return nil, fmt.Errorf("band %q: invalid color %q", band.Name, band.Color)
+		}
+	}
+	if sorted[len(sorted)-1].Min != 0 {
+		return nil, fmt.Errorf("the lowest band must start at 0")
+	}
+
+	return sorted, nil
+}
+
+// coverageBands returns the configured bands or the defaults.
+func (options Options) coverageBands() []Band {
+	if len(options.Bands) == 0 {
+		return DefaultBands
+	}
+	return options.Bands
+}
+
+// coverageClass returns the name of the band value falls in. Values are
+// compared at the precision they are displayed with, so "90.0%" is always
+// in a band starting at 90.
+func coverageClass(bands []Band, value float64) string {
+	rounded := RoundPercent(value)
+	for _, band := range bands {
+		if rounded >= band.Min {
+			return band.Name
+		}
+	}
+	return bands[len(bands)-1].Name
+}
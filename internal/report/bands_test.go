@@ -0,0 +1,52 @@
+package report
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseBands(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    []Band
+		wantErr string
+	}{
+		{
+			name: "default colors and sorting",
+			spec: "low=0,high=80",
+			want: []Band{{Name: "high", Min: 80, Color: "var(--covered)"}, {Name: "low", Min: 0, Color: "var(--missed)"}},
+		},
+		{
+			name: "color with commas",
+			spec: "good=50:rgb(0, 128, 0),bad=0:#f00",
+			want: []Band{{Name: "good", Min: 50, Color: "rgb(0, 128, 0)"}, {Name: "bad", Min: 0, Color: "#f00"}},
+		},
+		{name: "invalid name", spec: "High=0:red", wantErr: `invalid band name "High"`},
+		{name: "reserved name", spec: "swatch=0:red", wantErr: `invalid band name "swatch": reserved by the report`},
+		{name: "duplicate name", spec: "a=50:red,a=0:blue", wantErr: `duplicate band "a"`},
+		{name: "no zero band", spec: "a=50:red", wantErr: "the lowest band must start at 0"},
+		{name: "same minimum", spec: "a=0:red,b=0:blue", wantErr: "have the same minimum"},
+		{name: "missing color", spec: "custom=0", wantErr: `band "custom" needs a color`},
+		{name: "invalid color", spec: "a=0:url(x)", wantErr: `invalid color "url(x)"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseBands(test.spec)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("ParseBands(%q) error = %v, want %q", test.spec, err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseBands(%q) error = %v", test.spec, err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("ParseBands(%q) = %v, want %v", test.spec, got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CoberturaReport is the subset of a Cobertura XML document used to build a
+// report.
+type CoberturaReport struct {
+	Sources  []string
+	Packages []CoberturaPackage
+}
+
+// CoberturaPackage is a <package> element. Its name becomes the directory of
+// its classes in the report tree when the class file lies outside the root.
+type CoberturaPackage struct {
+	Name    string
+	Classes []CoberturaClass
+}
+
+// CoberturaClass is a <class> element with its line and branch data.
+type CoberturaClass struct {
+	Name     string
+	FileName string
+	Lines    []LineHits
+	Branches []BranchCoverage
+}
+
+type coberturaXML struct {
+	XMLName  xml.Name `xml:"coverage"`
+	Sources  []string `xml:"sources>source"`
+	Packages []struct {
+		Name    string `xml:"name,attr"`
+		Classes []struct {
+			Name     string          `xml:"name,attr"`
+			FileName string          `xml:"filename,attr"`
+			Lines    []coberturaLine `xml:"lines>line"`
+			Methods  []struct {
+				Lines []coberturaLine `xml:"lines>line"`
+			} `xml:"methods>method"`
+		} `xml:"classes>class"`
+	} `xml:"packages>package"`
+}
+
+type coberturaLine struct {
+	Number            int    `xml:"number,attr"`
+	Hits              string `xml:"hits,attr"`
+	Branch            bool   `xml:"branch,attr"`
+	ConditionCoverage string `xml:"condition-coverage,attr"`
+}
+
+var conditionPattern = regexp.MustCompile(`\((\d+)/(\d+)\)`)
+
+// ParseCobertura parses the Cobertura XML file at path. A path of "-" reads
+// from standard input.
+func ParseCobertura(path string) (CoberturaReport, error) {
+	reader, closer, err := openInput(path)
+	if err != nil {
+		return CoberturaReport{}, fmt.Errorf("parse cobertura: %w", err)
+	}
+	defer closer.Close()
+
+	return ParseCoberturaFromReader(reader)
+}
+
+// ParseCoberturaFromReader parses a Cobertura XML document from reader.
+func ParseCoberturaFromReader(reader io.Reader) (CoberturaReport, error) {
+	input, err := decompress(reader)
+	if err != nil {
+		return CoberturaReport{}, fmt.Errorf("parse cobertura: %w", err)
+	}
+
+	var document coberturaXML
+	if err := xml.NewDecoder(input).Decode(&document); err != nil {
+		return CoberturaReport{}, fmt.Errorf("parse cobertura: %w", err)
+	}
+
+	result := CoberturaReport{Sources: document.Sources}
+	for _, pkg := range document.Packages {
+		cobPackage := CoberturaPackage{Name: pkg.Name}
+		for _, class := range pkg.Classes {
+			lines := class.Lines
+			for _, method := range class.Methods {
+				lines = append(lines, method.Lines...)
+			}
+
+			cobClass := CoberturaClass{Name: class.Name, FileName: class.FileName}
+			seen := make(map[int]int)
+			for _, line := range lines {
+				hits, err := parseLCOVCount(line.Hits)
+				if err != nil {
+					return CoberturaReport{}, fmt.Errorf("parse cobertura: %s line %d: invalid hits: %w", class.FileName, line.Number, err)
+				}
+
+				// Method lines repeat the class lines, keep one entry per line.
+				if index, ok := seen[line.Number]; ok {
+					if hits > cobClass.Lines[index].Hits {
+						cobClass.Lines[index].Hits = hits
+					}
+					continue
+				}
+				seen[line.Number] = len(cobClass.Lines)
+				cobClass.Lines = append(cobClass.Lines, LineHits{Number: line.Number, Hits: hits})
+				if line.Branch {
+					cobClass.Branches = append(cobClass.Branches, conditionBranches(line.Number, line.ConditionCoverage)...)
+				}
+			}
+			cobPackage.Classes = append(cobPackage.Classes, cobClass)
+		}
+		result.Packages = append(result.Packages, cobPackage)
+	}
+
+	return result, nil
+}
+
+// conditionBranches expands a condition-coverage attribute such as
+// "50% (1/2)" into individual branches.
+func conditionBranches(line int, conditionCoverage string) []BranchCoverage {
+	match := conditionPattern.FindStringSubmatch(conditionCoverage)
+	if match == nil {
+		return nil
+	}
+	covered, _ := strconv.Atoi(match[1])
+	total, _ := strconv.Atoi(match[2])
+
+	branches := make([]BranchCoverage, 0, total)
+	for index := 0; index < total; index++ {
+		branch := BranchCoverage{Line: line, Branch: strconv.Itoa(index), Reached: true}
+		if index < covered {
+			branch.Taken = 1
+		}
+		branches = append(branches, branch)
+	}
+	return branches
+}
+
+// MergeCobertura concatenates the sources and packages of several documents.
+// Classes of the same file are merged when the report is generated.
+func MergeCobertura(reports ...CoberturaReport) CoberturaReport {
+	var merged CoberturaReport
+	seen := make(map[string]bool)
+	for _, report := range reports {
+		for _, source := range report.Sources {
+			if seen[source] {
+				continue
+			}
+			seen[source] = true
+			merged.Sources = append(merged.Sources, source)
+		}
+		merged.Packages = append(merged.Packages, report.Packages...)
+	}
+	return merged
+}
+
+// GenerateFromCobertura builds a report from a Cobertura document. Class
+// files are looked up under root and the document's <source> directories;
+// classes whose file lies outside root are placed under their package name.
+func GenerateFromCobertura(document CoberturaReport, options Options) (Report, error) {
+	resolver, err := newFileResolver(options.Root, nil, options.PathMappings)
+	if err != nil {
+		return Report{}, err
+	}
+
+	type coberturaFile struct {
+		name         string
+		sourcePath   string
+		relativePath string
+		lines        []LineHits
+		branches     []BranchCoverage
+	}
+
+	merged := make(map[string]*coberturaFile)
+	for _, pkg := range document.Packages {
+		for _, class := range pkg.Classes {
+			sourcePath, relativePath := resolveCoberturaFile(resolver, document.Sources, pkg.Name, class.FileName)
+			entry := merged[sourcePath]
+			if entry == nil {
+				entry = &coberturaFile{
+					name:         class.FileName,
+					sourcePath:   sourcePath,
+					relativePath: relativePath,
+				}
+				merged[sourcePath] = entry
+			}
+			entry.lines = append(entry.lines, class.Lines...)
+			entry.branches = append(entry.branches, class.Branches...)
+		}
+	}
+
+	keys := make([]string, 0, len(merged))
+	for key := range merged {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	files := make([]FileReport, 0, len(keys))
+	for _, key := range keys {
+		entry := merged[key]
+		record := MergeLCOV([]LCOVRecord{{SourceFile: entry.name, Lines: entry.lines, Branches: entry.branches}})[0]
+		files = append(files, buildLineFileReport(entry.name, entry.sourcePath, entry.relativePath, record.Lines, record.Branches, options.coverageBands()))
+	}
+
+	report, err := newReport(options, files)
+	if err != nil {
+		return Report{}, err
+	}
+	report.Mode = string(FormatCobertura)
+
+	return report, nil
+}
+
+func resolveCoberturaFile(resolver *fileResolver, sources []string, packageName, fileName string) (string, string) {
+	if _, ok := mapPath(resolver.mappings, fileName); ok {
+		return resolver.resolve(fileName)
+	}
+
+	candidates := make([]string, 0, len(sources)+1)
+	if filepath.IsAbs(fileName) {
+		candidates = append(candidates, fileName)
+	} else {
+		for _, source := range sources {
+			candidates = append(candidates, filepath.Join(source, filepath.FromSlash(fileName)))
+		}
+		candidates = append(candidates, filepath.Join(resolver.root, filepath.FromSlash(fileName)))
+	}
+
+	sourcePath := candidates[0]
+	for _, candidate := range candidates {
+		if fileExists(candidate) {
+			sourcePath = candidate
+			break
+		}
+	}
+
+	if absolute, err := filepath.Abs(sourcePath); err == nil {
+		if relative, err := filepath.Rel(resolver.root, absolute); err == nil && !strings.HasPrefix(relative, "..") {
+			return sourcePath, relative
+		}
+	}
+
+	if packageName != "" {
+		// Go import paths already use slashes; other tools separate with dots.
+		directory := packageName
+		if !strings.Contains(directory, "/") {
+			directory = strings.ReplaceAll(directory, ".", "/")
+		}
+		return sourcePath, path.Join(directory, path.Base(filepath.ToSlash(fileName)))
+	}
+	return sourcePath, filepath.ToSlash(fileName)
+}
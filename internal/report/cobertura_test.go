@@ -0,0 +1,110 @@
+package report
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCoberturaFromReader(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    CoberturaReport
+		wantErr string
+	}{
+		{
+			name: "lines, methods and branches",
+			input: `<?xml version="1.0"?>
+<!DOCTYPE coverage SYSTEM "http://cobertura.sourceforge.net/xml/coverage-04.dtd">
+<coverage line-rate="0.5" branch-rate="0.5" version="1.9">
+	<sources>
+		<source>/src/project</source>
+	</sources>
+	<packages>
+		<package name="example.com/a">
+			<classes>
+				<class name="a" filename="a/a.go">
+					<methods>
+						<method name="A" signature="">
+							<lines>
+								<line number="3" hits="4"/>
+							</lines>
+						</method>
+					</methods>
+					<lines>
+						<line number="3" hits="2" branch="true" condition-coverage="50% (1/2)"/>
+						<line number="4" hits="0"/>
+					</lines>
+				</class>
+			</classes>
+		</package>
+	</packages>
+</coverage>`,
+			want: CoberturaReport{
+				Sources: []string{"/src/project"},
+				Packages: []CoberturaPackage{{
+					Name: "example.com/a",
+					Classes: []CoberturaClass{{
+						Name:     "a",
+						FileName: "a/a.go",
+						Lines:    []LineHits{{Number: 3, Hits: 4}, {Number: 4}},
+						Branches: []BranchCoverage{
+							{Line: 3, Branch: "0", Taken: 1, Reached: true},
+							{Line: 3, Branch: "1", Reached: true},
+						},
+					}},
+				}},
+			},
+		},
+		{
+			name:  "lines only",
+			input: `<coverage><packages><package name="b"><classes><class name="b" filename="b.py"><lines><line number="1" hits="1"/><line number="2" hits="0"/></lines></class></classes></package></packages></coverage>`,
+			want: CoberturaReport{Packages: []CoberturaPackage{{
+				Name:    "b",
+				Classes: []CoberturaClass{{Name: "b", FileName: "b.py", Lines: []LineHits{{Number: 1, Hits: 1}, {Number: 2}}}},
+			}}},
+		},
+		{
+			name:  "branch without condition coverage",
+			input: `<coverage><packages><package name="c"><classes><class name="c" filename="c.go"><lines><line number="1" hits="1" branch="true"/></lines></class></classes></package></packages></coverage>`,
+			want: CoberturaReport{Packages: []CoberturaPackage{{
+				Name:    "c",
+				Classes: []CoberturaClass{{Name: "c", FileName: "c.go", Lines: []LineHits{{Number: 1, Hits: 1}}}},
+			}}},
+		},
+		{
+			name:    "invalid XML",
+			input:   `<coverage><packages>`,
+			wantErr: "parse cobertura: XML syntax error",
+		},
+		{
+			name:    "other document",
+			input:   `<report></report>`,
+			wantErr: "parse cobertura: expected element type <coverage>",
+		},
+		{
+			name:    "invalid hits",
+			input:   `<coverage><packages><package name="d"><classes><class name="d" filename="d.go"><lines><line number="7" hits="often"/></lines></class></classes></package></packages></coverage>`,
+			wantErr: "parse cobertura: d.go line 7: invalid hits",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseCoberturaFromReader(strings.NewReader(test.input))
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("ParseCoberturaFromReader() error = %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseCoberturaFromReader() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("ParseCoberturaFromReader() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,529 @@
+package report
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/cover"
+)
+
+// CoverDirSummary lists what contributed to coverage data read from
+// GOCOVERDIR directories written by binaries built with `go build -cover`.
+type CoverDirSummary struct {
+	Dirs     []string
+	Packages []string
+	Binaries []string
+}
+
+// The layout of the files follows internal/coverage of the Go distribution,
+// file format version 1 (Go 1.20 and later).
+const (
+	metaFilePrefix    = "covmeta."
+	metaFileVersion   = 1
+	metaHeaderSize    = 56
+	metaPackageHeader = 44
+
+	counterFilePrefix  = "covcounters."
+	counterFileVersion = 1
+	counterHeaderSize  = 32
+	counterSegmentSize = 16
+	counterFooterSize  = 16
+
+	counterFlavorRaw     = 1
+	counterFlavorULEB128 = 2
+
+	granularityPerFunc = 2
+)
+
+var (
+	metaMagic    = []byte{0x00, 0x63, 0x76, 0x6d}
+	counterMagic = []byte{0x00, 0x63, 0x77, 0x6d}
+)
+
+// coverModes are the profile modes by counter mode number.
+var coverModes = map[byte]string{1: "set", 2: "count", 3: "atomic"}
+
+// coverMeta is a decoded covmeta file: the coverable units of every
+// function of every package in a binary.
+type coverMeta struct {
+	path        string
+	hash        [16]byte
+	mode        string
+	granularity byte
+	packages    []coverPackage
+}
+
+type coverPackage struct {
+	path  string
+	funcs []coverFunc
+}
+
+type coverFunc struct {
+	file  string
+	units []coverUnit
+}
+
+// coverUnit is a block as in a coverprofile, without its count.
+type coverUnit struct {
+	file                                 string
+	startLine, startCol, endLine, endCol int
+	numStmt                              int
+}
+
+// coverCounters is a decoded covcounters file: the counters of the
+// functions that ran, by package and function index of its meta file.
+type coverCounters struct {
+	hash     [16]byte
+	binary   string
+	counters map[[2]uint32][]uint32
+}
+
+// ParseCoverDirs reads the covmeta/covcounters files in dirs into the same
+// profile model used for coverprofiles. Counters of the same block are
+// merged across runs and binaries like `go tool covdata textfmt` does, but
+// without running the go command, so the data does not have to match the
+// installed toolchain.
+func ParseCoverDirs(dirs []string) ([]*cover.Profile, CoverDirSummary, error) {
+	summary := CoverDirSummary{Dirs: dirs}
+	if len(dirs) == 0 {
+		return nil, summary, fmt.Errorf("no coverage directories given")
+	}
+
+	var metas []coverMeta
+	var counterFiles []coverCounters
+	for _, dir := range dirs {
+		info, err := os.Stat(dir)
+		if err != nil {
+			return nil, summary, fmt.Errorf("coverage directory: %w", err)
+		}
+		if !info.IsDir() {
+			return nil, summary, fmt.Errorf("coverage directory %s is not a directory", dir)
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, summary, fmt.Errorf("read coverage directory: %w", err)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			switch {
+			case strings.HasPrefix(entry.Name(), metaFilePrefix):
+				meta, err := readMetaFile(path)
+				if err != nil {
+					return nil, summary, fmt.Errorf("read %s: %w", path, err)
+				}
+				// Binaries built alike write identical meta files to
+				// every directory; their counters are merged once.
+				if !hasMeta(metas, meta.hash) {
+					metas = append(metas, meta)
+				}
+			case strings.HasPrefix(entry.Name(), counterFilePrefix):
+				counters, err := readCounterFile(path)
+				if err != nil {
+					return nil, summary, fmt.Errorf("read %s: %w", path, err)
+				}
+				counterFiles = append(counterFiles, counters)
+			}
+		}
+	}
+	if len(metas) == 0 {
+		return nil, summary, fmt.Errorf("no %s* files found in %s", metaFilePrefix, strings.Join(dirs, ", "))
+	}
+
+	mode := ""
+	for _, meta := range metas {
+		if mode == "" {
+			mode = meta.mode
+		} else if meta.mode != mode {
+			return nil, summary, fmt.Errorf("conflicting cover modes %q and %q in %s", mode, meta.mode, meta.path)
+		}
+	}
+
+	units := make(map[coverUnit]int)
+	packages := make(map[string]bool)
+	binaries := make(map[string]bool)
+	for _, meta := range metas {
+		merged := make(map[[2]uint32][]uint32)
+		for _, counterFile := range counterFiles {
+			if counterFile.hash != meta.hash {
+				continue
+			}
+			if counterFile.binary != "" {
+				binaries[counterFile.binary] = true
+			}
+			for key, counters := range counterFile.counters {
+				merged[key] = mergeCounters(merged[key], counters, mode)
+			}
+		}
+
+		for packageIndex, pkg := range meta.packages {
+			packages[pkg.path] = true
+			for funcIndex, function := range pkg.funcs {
+				counters := merged[[2]uint32{uint32(packageIndex), uint32(funcIndex)}]
+				for unitIndex, unit := range function.units {
+					count := 0
+					switch {
+					case meta.granularity == granularityPerFunc && len(counters) > 0:
+						count = int(counters[0])
+					case unitIndex < len(counters):
+						count = int(counters[unitIndex])
+					}
+					units[unit] = mergeCount(units[unit], count, mode)
+				}
+			}
+		}
+	}
+
+	summary.Packages = sortedKeys(packages)
+	summary.Binaries = sortedKeys(binaries)
+	return unitProfiles(units, mode), summary, nil
+}
+
+func hasMeta(metas []coverMeta, hash [16]byte) bool {
+	for _, meta := range metas {
+		if meta.hash == hash {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeCounters merges the counters of one function of two runs.
+func mergeCounters(target, source []uint32, mode string) []uint32 {
+	for len(target) < len(source) {
+		target = append(target, 0)
+	}
+	for index, count := range source {
+		target[index] = uint32(mergeCount(int(target[index]), int(count), mode))
+	}
+	return target
+}
+
+func mergeCount(left, right int, mode string) int {
+	if mode == "set" {
+		if left > 0 || right > 0 {
+			return 1
+		}
+		return 0
+	}
+	return left + right
+}
+
+// unitProfiles groups units into one profile per file, with the blocks
+// sorted as cover.ParseProfiles sorts them.
+func unitProfiles(units map[coverUnit]int, mode string) []*cover.Profile {
+	byFile := make(map[string]*cover.Profile)
+	for unit, count := range units {
+		profile := byFile[unit.file]
+		if profile == nil {
+			profile = &cover.Profile{FileName: unit.file, Mode: mode}
+			byFile[unit.file] = profile
+		}
+		profile.Blocks = append(profile.Blocks, cover.ProfileBlock{
+			StartLine: unit.startLine,
+			StartCol:  unit.startCol,
+			EndLine:   unit.endLine,
+			EndCol:    unit.endCol,
+			NumStmt:   unit.numStmt,
+			Count:     count,
+		})
+	}
+
+	profiles := make([]*cover.Profile, 0, len(byFile))
+	for _, profile := range byFile {
+		sort.Slice(profile.Blocks, func(i, j int) bool {
+			left, right := profile.Blocks[i], profile.Blocks[j]
+			if left.StartLine != right.StartLine {
+				return left.StartLine < right.StartLine
+			}
+			if left.StartCol != right.StartCol {
+				return left.StartCol < right.StartCol
+			}
+			if left.EndLine != right.EndLine {
+				return left.EndLine < right.EndLine
+			}
+			return left.EndCol < right.EndCol
+		})
+		profiles = append(profiles, profile)
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		return profiles[i].FileName < profiles[j].FileName
+	})
+	return profiles
+}
+
+// readMetaFile decodes a covmeta file: a header, the offsets and lengths
+// of the package payloads, and per package a header, the function offsets,
+// a string table and the functions with their units.
+func readMetaFile(path string) (coverMeta, error) {
+	meta := coverMeta{path: path}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return meta, err
+	}
+	if len(data) < metaHeaderSize || !bytes.Equal(data[:4], metaMagic) {
+		return meta, fmt.Errorf("not a coverage meta-data file")
+	}
+	if version := binary.LittleEndian.Uint32(data[4:8]); version > metaFileVersion {
+		return meta, fmt.Errorf("unsupported meta-data file version %d, expected %d", version, metaFileVersion)
+	}
+	entries := binary.LittleEndian.Uint64(data[16:24])
+	copy(meta.hash[:], data[24:40])
+	mode, ok := coverModes[data[48]]
+	if !ok {
+		return meta, fmt.Errorf("unsupported counter mode %d", data[48])
+	}
+	meta.mode = mode
+	meta.granularity = data[49]
+
+	if uint64(len(data)-metaHeaderSize)/16 < entries {
+		return meta, errShortTable
+	}
+	for index := uint64(0); index < entries; index++ {
+		offset := binary.LittleEndian.Uint64(data[metaHeaderSize+8*index:])
+		length := binary.LittleEndian.Uint64(data[metaHeaderSize+8*(entries+index):])
+		if offset > uint64(len(data)) || length > uint64(len(data))-offset {
+			return meta, fmt.Errorf("package %d is out of bounds", index)
+		}
+		pkg, err := readMetaPackage(data[offset : offset+length])
+		if err != nil {
+			return meta, fmt.Errorf("package %d: %w", index, err)
+		}
+		meta.packages = append(meta.packages, pkg)
+	}
+
+	return meta, nil
+}
+
+func readMetaPackage(payload []byte) (coverPackage, error) {
+	var pkg coverPackage
+	if len(payload) < metaPackageHeader {
+		return pkg, errShortTable
+	}
+	pathIndex := binary.LittleEndian.Uint32(payload[8:12])
+	numFuncs := binary.LittleEndian.Uint32(payload[40:44])
+	tableOffset := metaPackageHeader + 4*uint64(numFuncs)
+	if tableOffset > uint64(len(payload)) {
+		return pkg, errShortTable
+	}
+
+	table, err := readStringTable(payload[tableOffset:])
+	if err != nil {
+		return pkg, err
+	}
+	if uint64(pathIndex) >= uint64(len(table)) {
+		return pkg, fmt.Errorf("malformed package path")
+	}
+	pkg.path = table[pathIndex]
+
+	for index := uint32(0); index < numFuncs; index++ {
+		offset := binary.LittleEndian.Uint32(payload[metaPackageHeader+4*index:])
+		if uint64(offset) > uint64(len(payload)) {
+			return pkg, fmt.Errorf("function %d is out of bounds", index)
+		}
+		reader := &ulebReader{data: payload, offset: int(offset)}
+		numUnits := reader.next()
+		reader.next() // function name
+		fileIndex := reader.next()
+		if reader.err != nil || fileIndex >= uint64(len(table)) {
+			return pkg, fmt.Errorf("malformed function %d", index)
+		}
+
+		function := coverFunc{file: table[fileIndex]}
+		for unitIndex := uint64(0); unitIndex < numUnits && reader.err == nil; unitIndex++ {
+			function.units = append(function.units, coverUnit{
+				file:      function.file,
+				startLine: int(reader.next()),
+				startCol:  int(reader.next()),
+				endLine:   int(reader.next()),
+				endCol:    int(reader.next()),
+				numStmt:   int(reader.next()),
+			})
+		}
+		if reader.err != nil {
+			return pkg, fmt.Errorf("malformed function %d: %w", index, reader.err)
+		}
+		pkg.funcs = append(pkg.funcs, function)
+	}
+
+	return pkg, nil
+}
+
+// readCounterFile decodes a covcounters file: a header followed by
+// segments, one per run that wrote to the file. Each segment has a header,
+// a string table, the args of the run, the function counters and a footer.
+func readCounterFile(path string) (coverCounters, error) {
+	result := coverCounters{counters: make(map[[2]uint32][]uint32)}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return result, err
+	}
+	if len(data) < counterHeaderSize+counterFooterSize || !bytes.Equal(data[:4], counterMagic) {
+		return result, fmt.Errorf("not a counter data file")
+	}
+	if version := binary.LittleEndian.Uint32(data[4:8]); version > counterFileVersion {
+		return result, fmt.Errorf("unsupported counter data file version %d, expected %d", version, counterFileVersion)
+	}
+	copy(result.hash[:], data[8:24])
+	flavor := data[24]
+	var order binary.ByteOrder = binary.LittleEndian
+	if data[25] != 0 {
+		order = binary.BigEndian
+	}
+	if flavor != counterFlavorRaw && flavor != counterFlavorULEB128 {
+		return result, fmt.Errorf("unsupported counter flavor %d", flavor)
+	}
+
+	footer := data[len(data)-counterFooterSize:]
+	if !bytes.Equal(footer[:4], counterMagic) {
+		return result, fmt.Errorf("missing counter data file footer")
+	}
+	segments := binary.LittleEndian.Uint32(footer[8:12])
+
+	offset := counterHeaderSize
+	for segment := uint32(0); segment < segments; segment++ {
+		if len(data)-offset < counterSegmentSize {
+			return result, errShortTable
+		}
+		functions := binary.LittleEndian.Uint64(data[offset:])
+		strTabLen := int(binary.LittleEndian.Uint32(data[offset+8:]))
+		argsLen := int(binary.LittleEndian.Uint32(data[offset+12:]))
+		start := offset + counterSegmentSize
+		if strTabLen < 0 || argsLen < 0 || len(data)-start < strTabLen+argsLen {
+			return result, errShortTable
+		}
+
+		if segment == 0 {
+			table, err := readStringTable(data[start : start+strTabLen])
+			if err != nil {
+				return result, err
+			}
+			binaryName, err := readArgv0(table, data[start+strTabLen:start+strTabLen+argsLen])
+			if err != nil {
+				return result, err
+			}
+			result.binary = binaryName
+		}
+
+		reader := &ulebReader{data: data, offset: start + strTabLen + argsLen}
+		if padding := (reader.offset - offset) % 4; padding != 0 {
+			reader.offset += 4 - padding
+		}
+		value := func() uint32 {
+			if flavor == counterFlavorULEB128 {
+				return uint32(reader.next())
+			}
+			if len(reader.data)-reader.offset < 4 {
+				reader.err = errShortTable
+				return 0
+			}
+			reader.offset += 4
+			return order.Uint32(reader.data[reader.offset-4:])
+		}
+		for index := uint64(0); index < functions && reader.err == nil; index++ {
+			count := value()
+			key := [2]uint32{value(), value()}
+			counters := make([]uint32, 0, count)
+			for counter := uint32(0); counter < count && reader.err == nil; counter++ {
+				counters = append(counters, value())
+			}
+			result.counters[key] = mergeCounters(result.counters[key], counters, "count")
+		}
+		if reader.err != nil {
+			return result, fmt.Errorf("malformed counters: %w", reader.err)
+		}
+		offset = reader.offset + counterFooterSize
+	}
+
+	return result, nil
+}
+
+// readArgv0 returns argv[0] recorded in the args table of a segment.
+func readArgv0(table []string, data []byte) (string, error) {
+	args := &ulebReader{data: data}
+	count := args.next()
+	for index := uint64(0); index < count && args.err == nil; index++ {
+		key := args.next()
+		value := args.next()
+		if key >= uint64(len(table)) || value >= uint64(len(table)) {
+			return "", fmt.Errorf("malformed args table")
+		}
+		if table[key] == "argv0" {
+			return table[value], nil
+		}
+	}
+
+	return "", args.err
+}
+
+func readStringTable(data []byte) ([]string, error) {
+	reader := &ulebReader{data: data}
+	count := reader.next()
+	values := make([]string, 0)
+	for index := uint64(0); index < count && reader.err == nil; index++ {
+		length := reader.next()
+		values = append(values, reader.read(length))
+	}
+	if reader.err != nil {
+		return nil, fmt.Errorf("malformed string table: %w", reader.err)
+	}
+	return values, nil
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+type ulebReader struct {
+	data   []byte
+	offset int
+	err    error
+}
+
+var errShortTable = errors.New("unexpected end of table")
+
+func (reader *ulebReader) next() uint64 {
+	var value uint64
+	var shift uint
+	for reader.err == nil {
+		if reader.offset >= len(reader.data) {
+			reader.err = errShortTable
+			return 0
+		}
+		current := reader.data[reader.offset]
+		reader.offset++
+		value |= uint64(current&0x7f) << shift
+		if current&0x80 == 0 {
+			break
+		}
+		shift += 7
+	}
+	return value
+}
+
+func (reader *ulebReader) read(length uint64) string {
+	if reader.err != nil {
+		return ""
+	}
+	if uint64(len(reader.data)-reader.offset) < length {
+		reader.err = errShortTable
+		return ""
+	}
+	value := string(reader.data[reader.offset : reader.offset+int(length)])
+	reader.offset += int(length)
+	return value
+}
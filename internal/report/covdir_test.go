@@ -0,0 +1,71 @@
+package report
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"golang.org/x/tools/cover"
+)
+
+// testdata/covdata was written by two runs of a binary built with
+// `go build -cover -covermode=count`; testdata/covdata.txt is the output of
+// `go tool covdata textfmt` for it.
+func TestParseCoverDirs(t *testing.T) {
+	got, summary, err := ParseCoverDirs([]string{filepath.Join("testdata", "covdata")})
+	if err != nil {
+		t.Fatalf("ParseCoverDirs() error = %v", err)
+	}
+	want, err := cover.ParseProfiles(filepath.Join("testdata", "covdata.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseCoverDirs() = %s, want %s", formatProfiles(got), formatProfiles(want))
+	}
+
+	wantPackages := []string{"example.com/covbin", "example.com/covbin/lib"}
+	if !reflect.DeepEqual(summary.Packages, wantPackages) {
+		t.Errorf("ParseCoverDirs() packages = %v, want %v", summary.Packages, wantPackages)
+	}
+	if len(summary.Binaries) != 1 || filepath.Base(summary.Binaries[0]) != "app" {
+		t.Errorf("ParseCoverDirs() binaries = %v, want the app binary", summary.Binaries)
+	}
+}
+
+func TestParseCoverDirsSumsDirectories(t *testing.T) {
+	dir := filepath.Join("testdata", "covdata")
+	single, _, err := ParseCoverDirs([]string{dir})
+	if err != nil {
+		t.Fatalf("ParseCoverDirs() error = %v", err)
+	}
+	double, _, err := ParseCoverDirs([]string{dir, dir})
+	if err != nil {
+		t.Fatalf("ParseCoverDirs() error = %v", err)
+	}
+	for fileIndex, profile := range double {
+		for blockIndex, block := range profile.Blocks {
+			if want := 2 * single[fileIndex].Blocks[blockIndex].Count; block.Count != want {
+				t.Errorf("%s block %s count = %d, want %d", profile.FileName, formatBlock(block), block.Count, want)
+			}
+		}
+	}
+}
+
+func TestParseCoverDirsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		dirs []string
+	}{
+		{name: "no directories"},
+		{name: "missing directory", dirs: []string{filepath.Join("testdata", "missing")}},
+		{name: "no meta files", dirs: []string{t.TempDir()}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, _, err := ParseCoverDirs(test.dirs); err == nil {
+				t.Error("ParseCoverDirs() error = nil, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package report
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Delta statuses of files and directories that exist in only one of the
+// compared reports.
+const (
+	DeltaNew     = "new"
+	DeltaDeleted = "deleted"
+)
+
+// Delta is the coverage change of the whole report, a directory or a file
+// between a base and a head report.
+type Delta struct {
+	Status       string
+	BasePercent  string
+	Percent      float64
+	CoveredStmts int
+	TotalStmts   int
+}
+
+// PercentText formats the change in percentage points with its sign.
+func (delta Delta) PercentText() string {
+	rounded := RoundPercent(delta.Percent)
+	if rounded == 0 {
+		return "±0.0"
+	}
+	return fmt.Sprintf("%+.1f", rounded)
+}
+
+// StmtsText formats the change of covered and total statements, such as
+// "+3 / +5 statements".
+func (delta Delta) StmtsText() string {
+	return fmt.Sprintf("%+d / %+d statements", delta.CoveredStmts, delta.TotalStmts)
+}
+
+// Class returns the CSS class of the change: "up", "down" or "same".
+func (delta Delta) Class() string {
+	switch rounded := RoundPercent(delta.Percent); {
+	case rounded > 0:
+		return "up"
+	case rounded < 0:
+		return "down"
+	default:
+		return "same"
+	}
+}
+
+// Dropped reports whether coverage went down.
+func (delta Delta) Dropped() bool {
+	return delta.Class() == "down"
+}
+
+// Comparison describes the base a report was compared with.
+type Comparison struct {
+	Base         string
+	Head         string
+	Total        Delta
+	NewFiles     int
+	DeletedFiles []FileReport
+}
+
+// Compare annotates head with the coverage changes since base. Files are
+// matched by name and tree nodes by path. Files and tree nodes whose
+// coverage dropped are moved to the front of head.Files and of their tree
+// level, largest drop first. Files that only exist in base are listed in
+// head.Comparison.DeletedFiles, and base tree nodes missing from head are
+// added to head.Tree as deleted.
+func Compare(base, head *Report, baseName, headName string) {
+	comparison := &Comparison{
+		Base:  baseName,
+		Head:  headName,
+		Total: newDelta(base.CoveredStmts, base.TotalStmts, head.CoveredStmts, head.TotalStmts),
+	}
+
+	baseFiles := make(map[string]*FileReport, len(base.Files))
+	for index := range base.Files {
+		baseFiles[base.Files[index].Name] = &base.Files[index]
+	}
+
+	headFiles := make(map[string]bool, len(head.Files))
+	for index := range head.Files {
+		file := &head.Files[index]
+		headFiles[file.Name] = true
+		baseFile := baseFiles[file.Name]
+		if baseFile == nil {
+			file.Delta = &Delta{Status: DeltaNew}
+			comparison.NewFiles++
+			continue
+		}
+		delta := newDelta(baseFile.CoveredStmts, baseFile.TotalStmts, file.CoveredStmts, file.TotalStmts)
+		file.Delta = &delta
+	}
+
+	for _, file := range base.Files {
+		if !headFiles[file.Name] {
+			comparison.DeletedFiles = append(comparison.DeletedFiles, file)
+		}
+	}
+
+	head.Tree = compareTree(head.Tree, base.Tree)
+	sort.SliceStable(head.Files, func(i, j int) bool {
+		return droppedBefore(head.Files[i].Delta, head.Files[j].Delta)
+	})
+
+	head.Comparison = comparison
+}
+
+func newDelta(baseCovered, baseTotal, headCovered, headTotal int) Delta {
+	basePercent := percent(baseCovered, baseTotal)
+	return Delta{
+		BasePercent:  formatPercent(basePercent),
+		Percent:      percent(headCovered, headTotal) - basePercent,
+		CoveredStmts: headCovered - baseCovered,
+		TotalStmts:   headTotal - baseTotal,
+	}
+}
+
+// compareTree sets the deltas of nodes against the base nodes at the same
+// level, adds the base nodes missing from nodes as deleted and orders every
+// level like Compare orders the files, keeping directories first.
+func compareTree(nodes, baseNodes []TreeNode) []TreeNode {
+	if len(nodes) == 0 && len(baseNodes) == 0 {
+		return nodes
+	}
+
+	baseByPath := make(map[string]*TreeNode, len(baseNodes))
+	for index := range baseNodes {
+		baseByPath[baseNodes[index].Path] = &baseNodes[index]
+	}
+
+	compared := make([]TreeNode, 0, len(nodes)+len(baseNodes))
+	headPaths := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		headPaths[node.Path] = true
+		baseNode := baseByPath[node.Path]
+		if baseNode == nil {
+			node.Delta = &Delta{Status: DeltaNew}
+			node.Children = compareTree(node.Children, nil)
+		} else {
+			delta := newDelta(baseNode.CoveredStmts, baseNode.TotalStmts, node.CoveredStmts, node.TotalStmts)
+			node.Delta = &delta
+			node.Children = compareTree(node.Children, baseNode.Children)
+		}
+		compared = append(compared, node)
+	}
+	for _, baseNode := range baseNodes {
+		if !headPaths[baseNode.Path] {
+			compared = append(compared, deletedNode(baseNode))
+		}
+	}
+
+	sort.SliceStable(compared, func(i, j int) bool {
+		if compared[i].IsDir != compared[j].IsDir {
+			return compared[i].IsDir
+		}
+		return droppedBefore(compared[i].Delta, compared[j].Delta)
+	})
+	return compared
+}
+
+// deletedNode returns a copy of the base node and its children marked as
+// deleted. Its files have no section in the head report, so they get no
+// anchor.
+func deletedNode(node TreeNode) TreeNode {
+	var children []TreeNode
+	for _, child := range node.Children {
+		children = append(children, deletedNode(child))
+	}
+	node.Children = children
+	node.Anchor = ""
+	node.Untested = false
+	node.BelowThreshold = false
+	node.Trend = nil
+	node.Delta = &Delta{Status: DeltaDeleted}
+	return node
+}
+
+// droppedBefore reports whether an entry with the left delta comes before
+// one with the right delta: dropped entries first, largest drop first.
+func droppedBefore(left, right *Delta) bool {
+	leftDropped := left != nil && left.Dropped()
+	rightDropped := right != nil && right.Dropped()
+	if leftDropped && rightDropped {
+		return left.Percent < right.Percent
+	}
+	return leftDropped && !rightDropped
+}
+
+// RoundPercent rounds to the one decimal place percentages are displayed
+// and compared with.
+func RoundPercent(value float64) float64 {
+	rounded := math.Round(value*10) / 10
+	if rounded == 0 {
+		// Drop the sign of a small negative change, which would show as -0.
+		return 0
+	}
+	return rounded
+}
@@ -0,0 +1,87 @@
+package report
+
+import (
+	"reflect"
+	"testing"
+)
+
+func deltaTestFile(name string, covered, total int) FileReport {
+	return FileReport{Name: name, RelativeSourcePath: name, Anchor: sanitizeAnchor(name), CoveredStmts: covered, TotalStmts: total}
+}
+
+func deltaTestReport(files ...FileReport) *Report {
+	report := &Report{Files: files}
+	for _, file := range files {
+		report.CoveredStmts += file.CoveredStmts
+		report.TotalStmts += file.TotalStmts
+	}
+	report.Tree = buildTree(report.Files, DefaultBands)
+	return report
+}
+
+// treeSummary lists the paths of nodes depth first with their delta status or
+// class.
+func treeSummary(nodes []TreeNode) []string {
+	var summary []string
+	for _, node := range nodes {
+		state := "none"
+		if node.Delta != nil {
+			state = node.Delta.Status
+			if state == "" {
+				state = node.Delta.Class()
+			}
+		}
+		summary = append(summary, node.Path+" "+state)
+		summary = append(summary, treeSummary(node.Children)...)
+	}
+	return summary
+}
+
+func TestCompare(t *testing.T) {
+	base := deltaTestReport(
+		deltaTestFile("a/one.go", 10, 10),
+		deltaTestFile("a/two.go", 10, 10),
+		deltaTestFile("b/three.go", 5, 10),
+		deltaTestFile("gone/four.go", 3, 4),
+	)
+	head := deltaTestReport(
+		deltaTestFile("a/one.go", 10, 10),
+		deltaTestFile("a/two.go", 2, 10),
+		deltaTestFile("b/three.go", 1, 10),
+		deltaTestFile("c/five.go", 1, 1),
+	)
+
+	Compare(base, head, "base.out", "head.out")
+
+	fileOrder := make([]string, 0, len(head.Files))
+	for _, file := range head.Files {
+		fileOrder = append(fileOrder, file.Name)
+	}
+	wantFiles := []string{"a/two.go", "b/three.go", "a/one.go", "c/five.go"}
+	if !reflect.DeepEqual(fileOrder, wantFiles) {
+		t.Errorf("file order = %v, want %v", fileOrder, wantFiles)
+	}
+
+	wantTree := []string{
+		"a down",
+		"a/two.go down",
+		"a/one.go same",
+		"b down",
+		"b/three.go down",
+		"c new",
+		"c/five.go new",
+		"gone deleted",
+		"gone/four.go deleted",
+	}
+	if got := treeSummary(head.Tree); !reflect.DeepEqual(got, wantTree) {
+		t.Errorf("tree = %v, want %v", got, wantTree)
+	}
+
+	deleted := head.Tree[3].Children[0]
+	if !deleted.Deleted() || deleted.Anchor != "" || deleted.CoveragePercent != base.Tree[2].Children[0].CoveragePercent {
+		t.Errorf("deleted node = %+v, want base coverage without anchor", deleted)
+	}
+	if len(head.Comparison.DeletedFiles) != 1 || head.Comparison.NewFiles != 1 {
+		t.Errorf("deleted files = %d, new files = %d, want 1, 1", len(head.Comparison.DeletedFiles), head.Comparison.NewFiles)
+	}
+}
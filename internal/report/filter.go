@@ -0,0 +1,172 @@
+package report
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// RegexPrefix marks an include or exclude pattern as a regular expression
+// instead of a glob.
+const RegexPrefix = "re:"
+
+// ExcludedFile is a file left out of the totals and the tree.
+type ExcludedFile struct {
+	Name         string
+	RelativePath string
+	Reason       string
+}
+
+type filePattern struct {
+	source   string
+	regex    *regexp.Regexp
+	baseName bool
+}
+
+type fileFilter struct {
+	include          []filePattern
+	exclude          []filePattern
+	includeGenerated bool
+}
+
+func newFileFilter(options Options) (*fileFilter, error) {
+	include, err := compilePatterns(options.Include)
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := compilePatterns(options.Exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fileFilter{
+		include:          include,
+		exclude:          exclude,
+		includeGenerated: options.IncludeGenerated,
+	}, nil
+}
+
+func compilePatterns(patterns []string) ([]filePattern, error) {
+	compiled := make([]filePattern, 0, len(patterns))
+	for _, pattern := range patterns {
+		if strings.HasPrefix(pattern, RegexPrefix) {
+			regex, err := regexp.Compile(strings.TrimPrefix(pattern, RegexPrefix))
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+			}
+			compiled = append(compiled, filePattern{source: pattern, regex: regex})
+			continue
+		}
+
+		regex, err := regexp.Compile(globToRegex(pattern))
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		compiled = append(compiled, filePattern{
+			source:   pattern,
+			regex:    regex,
+			baseName: !strings.Contains(pattern, "/"),
+		})
+	}
+	return compiled, nil
+}
+
+// globToRegex translates a glob where * and ? stop at slashes and **
+// matches any number of path segments.
+func globToRegex(glob string) string {
+	var builder strings.Builder
+	builder.WriteString("^")
+	for index := 0; index < len(glob); index++ {
+		char := glob[index]
+		switch char {
+		case '*':
+			if index+1 < len(glob) && glob[index+1] == '*' {
+				index++
+				if index+1 < len(glob) && glob[index+1] == '/' {
+					index++
+					builder.WriteString("(?:.*/)?")
+				} else {
+					builder.WriteString(".*")
+				}
+				continue
+			}
+			builder.WriteString("[^/]*")
+		case '?':
+			builder.WriteString("[^/]")
+		default:
+			builder.WriteString(regexp.QuoteMeta(string(char)))
+		}
+	}
+	builder.WriteString("$")
+	return builder.String()
+}
+
+func (pattern filePattern) matches(file FileReport) bool {
+	candidates := []string{file.Name, path.Dir(file.Name)}
+	if file.RelativeSourcePath != "" {
+		relative := filepath.ToSlash(file.RelativeSourcePath)
+		candidates = append(candidates, relative, path.Dir(relative))
+	}
+	if pattern.baseName {
+		candidates = append(candidates, path.Base(file.Name))
+	}
+
+	for _, candidate := range candidates {
+		if pattern.regex.MatchString(candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+// exclusionReason returns why file is excluded, or an empty string when it
+// is kept.
+func (filter *fileFilter) exclusionReason(file FileReport) string {
+	if len(filter.include) > 0 {
+		included := false
+		for _, pattern := range filter.include {
+			if pattern.matches(file) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return "not matched by include patterns"
+		}
+	}
+
+	for _, pattern := range filter.exclude {
+		if pattern.matches(file) {
+			return "matched " + pattern.source
+		}
+	}
+
+	if file.Generated && !filter.includeGenerated {
+		return "generated code"
+	}
+
+	return ""
+}
+
+var generatedPattern = regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
+
+// isGenerated reports whether content carries the standard generated-code
+// header before the first non-comment, non-blank line.
+func isGenerated(content []byte) bool {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if generatedPattern.MatchString(line) {
+			return true
+		}
+		trimmed := strings.TrimSpace(line)
+		if trimmed != "" && !strings.HasPrefix(trimmed, "//") {
+			return false
+		}
+	}
+	return false
+}
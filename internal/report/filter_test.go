@@ -0,0 +1,124 @@
+package report
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFileFilterExclusionReason(t *testing.T) {
+	tests := []struct {
+		name    string
+		options Options
+		file    FileReport
+		want    string
+	}{
+		{
+			name: "no patterns",
+			file: FileReport{Name: "example.com/m/a/a.go"},
+		},
+		{
+			name:    "double star matches any directories",
+			options: Options{Exclude: []string{"**/mocks/**"}},
+			file:    FileReport{Name: "example.com/m/a/mocks/deep/m.go"},
+			want:    "matched **/mocks/**",
+		},
+		{
+			name:    "leading double star matches no directory",
+			options: Options{Exclude: []string{"**/a.go"}},
+			file:    FileReport{Name: "a.go"},
+			want:    "matched **/a.go",
+		},
+		{
+			name:    "double star does not match part of a segment",
+			options: Options{Exclude: []string{"**/mocks/**"}},
+			file:    FileReport{Name: "example.com/m/nomocks/m.go"},
+		},
+		{
+			name:    "single star stops at slashes",
+			options: Options{Exclude: []string{"example.com/m/*.go"}},
+			file:    FileReport{Name: "example.com/m/a/a.go"},
+		},
+		{
+			name:    "pattern without slash matches the base name",
+			options: Options{Exclude: []string{"*.pb.go"}},
+			file:    FileReport{Name: "example.com/m/api/api.pb.go"},
+			want:    "matched *.pb.go",
+		},
+		{
+			name:    "relative source path",
+			options: Options{Exclude: []string{"internal/**"}},
+			file:    FileReport{Name: "example.com/m/internal/x/x.go", RelativeSourcePath: "internal/x/x.go"},
+			want:    "matched internal/**",
+		},
+		{
+			name:    "directory of the file",
+			options: Options{Exclude: []string{"example.com/m/a"}},
+			file:    FileReport{Name: "example.com/m/a/a.go"},
+			want:    "matched example.com/m/a",
+		},
+		{
+			name:    "regular expression",
+			options: Options{Exclude: []string{"re:_string\\.go$"}},
+			file:    FileReport{Name: "example.com/m/kind_string.go"},
+			want:    "matched re:_string\\.go$",
+		},
+		{
+			name:    "not included",
+			options: Options{Include: []string{"**/pkg/**"}},
+			file:    FileReport{Name: "example.com/m/cmd/main.go"},
+			want:    "not matched by include patterns",
+		},
+		{
+			name:    "included",
+			options: Options{Include: []string{"**/pkg/**"}},
+			file:    FileReport{Name: "example.com/m/pkg/p.go"},
+		},
+		{
+			name:    "exclude wins over include",
+			options: Options{Include: []string{"**/pkg/**"}, Exclude: []string{"**/pkg/internal/**"}},
+			file:    FileReport{Name: "example.com/m/pkg/internal/i.go"},
+			want:    "matched **/pkg/internal/**",
+		},
+		{
+			name:    "include is checked first",
+			options: Options{Include: []string{"**/pkg/**"}, Exclude: []string{"*.go"}},
+			file:    FileReport{Name: "example.com/m/cmd/main.go"},
+			want:    "not matched by include patterns",
+		},
+		{
+			name: "generated code",
+			file: FileReport{Name: "example.com/m/gen.go", Generated: true},
+			want: "generated code",
+		},
+		{
+			name:    "generated code included",
+			options: Options{IncludeGenerated: true},
+			file:    FileReport{Name: "example.com/m/gen.go", Generated: true},
+		},
+		{
+			name:    "generated code included but excluded by pattern",
+			options: Options{IncludeGenerated: true, Exclude: []string{"gen.go"}},
+			file:    FileReport{Name: "example.com/m/gen.go", Generated: true},
+			want:    "matched gen.go",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filter, err := newFileFilter(test.options)
+			if err != nil {
+				t.Fatalf("newFileFilter() error = %v", err)
+			}
+			if got := filter.exclusionReason(test.file); got != test.want {
+				t.Errorf("exclusionReason(%s) = %q, want %q", test.file.Name, got, test.want)
+			}
+		})
+	}
+}
+
+func TestNewFileFilterInvalidPattern(t *testing.T) {
+	_, err := newFileFilter(Options{Include: []string{"re:("}})
+	if want := `invalid pattern "re:("`; err == nil || !strings.Contains(err.Error(), want) {
+		t.Fatalf("newFileFilter() error = %v, want %q", err, want)
+	}
+}
@@ -0,0 +1,161 @@
+package report
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sort"
+)
+
+// leastCoveredLimit is the number of functions listed in
+// Report.LeastCovered.
+const leastCoveredLimit = 10
+
+type FunctionCoverage struct {
+	Name            string
+	Receiver        string
+	StartLine       int
+	EndLine         int
+	CoveredStmts    int
+	TotalStmts      int
+	CoveragePercent string
+	CoverageClass   string
+}
+
+// DisplayName returns the function name qualified with its receiver type, as
+// printed by `go tool cover -func`.
+func (function FunctionCoverage) DisplayName() string {
+	if function.Receiver == "" {
+		return function.Name
+	}
+	return "(" + function.Receiver + ")." + function.Name
+}
+
+// Percent returns the statement coverage of the function.
+func (function FunctionCoverage) Percent() float64 {
+	return percent(function.CoveredStmts, function.TotalStmts)
+}
+
+// FunctionSummary is a function together with the file it belongs to.
+type FunctionSummary struct {
+	FunctionCoverage
+	FileName string
+	Anchor   string
+}
+
+// buildFunctions maps blocks onto the function declarations of a source file,
+// following the same rules as `go tool cover -func`.
+func buildFunctions(sourcePath string, content []byte, blocks []BlockCoverage, bands []Band) []FunctionCoverage {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, sourcePath, content, 0)
+	if err != nil {
+		return nil
+	}
+
+	sorted := make([]BlockCoverage, len(blocks))
+	copy(sorted, blocks)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].StartLine != sorted[j].StartLine {
+			return sorted[i].StartLine < sorted[j].StartLine
+		}
+		return sorted[i].StartCol < sorted[j].StartCol
+	})
+
+	functions := make([]FunctionCoverage, 0)
+	for _, decl := range file.Decls {
+		funcDecl, ok := decl.(*ast.FuncDecl)
+		if !ok || funcDecl.Body == nil {
+			continue
+		}
+
+		start := fset.Position(funcDecl.Pos())
+		end := fset.Position(funcDecl.End())
+		function := FunctionCoverage{
+			Name:      funcDecl.Name.Name,
+			Receiver:  receiverName(funcDecl),
+			StartLine: start.Line,
+			EndLine:   end.Line,
+		}
+
+		for _, block := range sorted {
+			if block.StartLine < start.Line || (block.StartLine == start.Line && block.StartCol < start.Column) {
+				continue
+			}
+			if block.EndLine > end.Line || (block.EndLine == end.Line && block.EndCol > end.Column) {
+				break
+			}
+			if block.Ignored {
+				continue
+			}
+			function.TotalStmts += block.NumStmt
+			if block.Hits > 0 {
+				function.CoveredStmts += block.NumStmt
+			}
+		}
+
+		coveragePercent := percent(function.CoveredStmts, function.TotalStmts)
+		function.CoveragePercent = formatPercent(coveragePercent)
+		function.CoverageClass = coverageClass(bands, coveragePercent)
+		functions = append(functions, function)
+	}
+
+	return functions
+}
+
+func receiverName(funcDecl *ast.FuncDecl) string {
+	if funcDecl.Recv == nil || len(funcDecl.Recv.List) == 0 {
+		return ""
+	}
+	return typeName(funcDecl.Recv.List[0].Type)
+}
+
+func typeName(expr ast.Expr) string {
+	switch typed := expr.(type) {
+	case *ast.Ident:
+		return typed.Name
+	case *ast.StarExpr:
+		return "*" + typeName(typed.X)
+	case *ast.IndexExpr:
+		return typeName(typed.X)
+	case *ast.IndexListExpr:
+		return typeName(typed.X)
+	case *ast.ParenExpr:
+		return typeName(typed.X)
+	default:
+		return ""
+	}
+}
+
+// leastCoveredFunctions returns the functions with the lowest coverage
+// across all files, breaking ties by the number of uncovered statements.
+func leastCoveredFunctions(files []FileReport, limit int) []FunctionSummary {
+	summaries := make([]FunctionSummary, 0)
+	for _, file := range files {
+		for _, function := range file.Functions {
+			if function.TotalStmts == 0 || function.CoveredStmts == function.TotalStmts {
+				continue
+			}
+			summaries = append(summaries, FunctionSummary{
+				FunctionCoverage: function,
+				FileName:         file.Name,
+				Anchor:           file.Anchor,
+			})
+		}
+	}
+
+	sort.SliceStable(summaries, func(i, j int) bool {
+		left := percent(summaries[i].CoveredStmts, summaries[i].TotalStmts)
+		right := percent(summaries[j].CoveredStmts, summaries[j].TotalStmts)
+		if left != right {
+			return left < right
+		}
+		leftMissed := summaries[i].TotalStmts - summaries[i].CoveredStmts
+		rightMissed := summaries[j].TotalStmts - summaries[j].CoveredStmts
+		return leftMissed > rightMissed
+	})
+
+	if len(summaries) > limit {
+		summaries = summaries[:limit]
+	}
+	return summaries
+}
@@ -0,0 +1,208 @@
+package report
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+)
+
+// historyLimit is the number of most recent runs shown in a trend.
+const historyLimit = 30
+
+// HistoryRecord is the summary of one run stored as a line of a history
+// file. Packages holds every directory of the report tree by path.
+type HistoryRecord struct {
+	Timestamp time.Time                  `json:"timestamp"`
+	Commit    string                     `json:"commit,omitempty"`
+	Covered   int                        `json:"covered"`
+	Total     int                        `json:"total"`
+	Packages  map[string]HistoryCoverage `json:"packages,omitempty"`
+}
+
+// HistoryCoverage is the statement coverage of one directory in a
+// HistoryRecord.
+type HistoryCoverage struct {
+	Covered int `json:"covered"`
+	Total   int `json:"total"`
+}
+
+// Trend is the coverage of the total or a directory over recent runs, oldest
+// first.
+type Trend struct {
+	Points []TrendPoint
+}
+
+// TrendPoint is the coverage of one run in a Trend.
+type TrendPoint struct {
+	Timestamp time.Time
+	Commit    string
+	Percent   float64
+}
+
+// NewHistoryRecord summarizes report as a history record.
+func NewHistoryRecord(report Report, commit string, timestamp time.Time) HistoryRecord {
+	record := HistoryRecord{
+		Timestamp: timestamp.UTC().Truncate(time.Second),
+		Commit:    commit,
+		Covered:   report.CoveredStmts,
+		Total:     report.TotalStmts,
+		Packages:  make(map[string]HistoryCoverage),
+	}
+	addHistoryPackages(record.Packages, report.Tree)
+	return record
+}
+
+func addHistoryPackages(packages map[string]HistoryCoverage, nodes []TreeNode) {
+	for _, node := range nodes {
+		if !node.IsDir {
+			continue
+		}
+		packages[node.Path] = HistoryCoverage{Covered: node.CoveredStmts, Total: node.TotalStmts}
+		addHistoryPackages(packages, node.Children)
+	}
+}
+
+// ReadHistory reads the records of the history file at path. A missing file
+// is an empty history.
+func ReadHistory(path string) ([]HistoryRecord, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read history: %w", err)
+	}
+	defer file.Close()
+
+	records := make([]HistoryRecord, 0)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var record HistoryRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			return nil, fmt.Errorf("read history: %s:%d: %w", path, lineNumber, err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read history: %w", err)
+	}
+
+	return records, nil
+}
+
+// AppendHistory appends record as one JSON line to the history file at path,
+// creating the file if needed.
+func AppendHistory(path string, record HistoryRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("append history: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("append history: %w", err)
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return fmt.Errorf("append history: %w", err)
+	}
+	return file.Close()
+}
+
+// GitCommit returns the commit checked out in the git repository containing
+// root, or an empty string outside a repository.
+func GitCommit(root string) string {
+	output, err := runCommand(absoluteRoot(root), "git", "rev-parse", "HEAD")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// ApplyHistory sets the trends of the report total and of every tree
+// directory from the most recent records.
+func ApplyHistory(report *Report, records []HistoryRecord) {
+	if len(records) > historyLimit {
+		records = records[len(records)-historyLimit:]
+	}
+	if len(records) == 0 {
+		return
+	}
+
+	total := &Trend{}
+	for _, record := range records {
+		total.Points = append(total.Points, TrendPoint{
+			Timestamp: record.Timestamp,
+			Commit:    record.Commit,
+			Percent:   percent(record.Covered, record.Total),
+		})
+	}
+	report.Trend = total
+
+	applyTreeHistory(report.Tree, records)
+}
+
+func applyTreeHistory(nodes []TreeNode, records []HistoryRecord) {
+	for index := range nodes {
+		node := &nodes[index]
+		if !node.IsDir {
+			continue
+		}
+
+		trend := &Trend{}
+		for _, record := range records {
+			coverage, ok := record.Packages[node.Path]
+			if !ok {
+				continue
+			}
+			trend.Points = append(trend.Points, TrendPoint{
+				Timestamp: record.Timestamp,
+				Commit:    record.Commit,
+				Percent:   percent(coverage.Covered, coverage.Total),
+			})
+		}
+		if len(trend.Points) > 0 {
+			node.Trend = trend
+		}
+		applyTreeHistory(node.Children, records)
+	}
+}
+
+// Polyline returns the points attribute of an SVG polyline drawing the
+// trend in a width by height box, with 0% at the bottom and 100% at the top.
+func (trend Trend) Polyline(width, height int) string {
+	points := trend.Points
+	if len(points) == 1 {
+		points = []TrendPoint{points[0], points[0]}
+	}
+
+	coordinates := make([]string, 0, len(points))
+	for index, point := range points {
+		x := float64(width) * float64(index) / float64(len(points)-1)
+		y := float64(height) * (1 - point.Percent/100)
+		coordinates = append(coordinates, fmt.Sprintf("%.1f,%.1f", x, y))
+	}
+	return strings.Join(coordinates, " ")
+}
+
+// Summary describes the trend for a tooltip.
+func (trend Trend) Summary() string {
+	if len(trend.Points) == 0 {
+		return ""
+	}
+	first := trend.Points[0]
+	last := trend.Points[len(trend.Points)-1]
+	return fmt.Sprintf("%d runs since %s: %s → %s", len(trend.Points), first.Timestamp.Format("2006-01-02"), formatPercent(first.Percent), formatPercent(last.Percent))
+}
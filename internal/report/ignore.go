@@ -0,0 +1,179 @@
+package report
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+
+	"golang.org/x/tools/cover"
+)
+
+// Annotations recognised in source comments to leave code out of the totals.
+const (
+	ignoreAnnotation      = "coverage:ignore"
+	ignoreStartAnnotation = "coverage:ignore-start"
+	ignoreEndAnnotation   = "coverage:ignore-end"
+)
+
+// ignoredLines returns, indexed by line number, the lines excluded by
+// //coverage:ignore annotations:
+//
+//   - a trailing //coverage:ignore ignores its own line, a standalone one
+//     ignores the next line;
+//   - //coverage:ignore-start and //coverage:ignore-end ignore the lines
+//     between them;
+//   - //coverage:ignore in a function doc comment ignores the whole function.
+//
+// It returns nil when the source has no annotations or cannot be parsed.
+func ignoredLines(sourcePath string, content []byte) []bool {
+	if !strings.Contains(string(content), ignoreAnnotation) {
+		return nil
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, sourcePath, content, parser.ParseComments)
+	if err != nil {
+		return nil
+	}
+
+	lineCount := strings.Count(string(content), "\n") + 1
+	ignored := make([]bool, lineCount+1)
+	mark := func(start, end int) {
+		if start < 1 {
+			start = 1
+		}
+		if end > lineCount {
+			end = lineCount
+		}
+		for line := start; line <= end; line++ {
+			ignored[line] = true
+		}
+	}
+
+	lines := strings.Split(string(content), "\n")
+	regionStart := 0
+	for _, group := range file.Comments {
+		for _, comment := range group.List {
+			annotation := commentAnnotation(comment.Text)
+			position := fset.Position(comment.Pos())
+			switch annotation {
+			case ignoreStartAnnotation:
+				if regionStart == 0 {
+					regionStart = position.Line
+				}
+			case ignoreEndAnnotation:
+				if regionStart != 0 {
+					mark(regionStart+1, position.Line-1)
+					regionStart = 0
+				}
+			case ignoreAnnotation:
+				prefix := lines[position.Line-1][:position.Column-1]
+				if strings.TrimSpace(prefix) != "" {
+					mark(position.Line, position.Line)
+				} else {
+					mark(position.Line, nextCodeLine(lines, position.Line))
+				}
+			}
+		}
+	}
+	if regionStart != 0 {
+		mark(regionStart+1, lineCount)
+	}
+
+	for _, decl := range file.Decls {
+		funcDecl, ok := decl.(*ast.FuncDecl)
+		if !ok || funcDecl.Doc == nil {
+			continue
+		}
+		for _, comment := range funcDecl.Doc.List {
+			if commentAnnotation(comment.Text) == ignoreAnnotation {
+				mark(fset.Position(funcDecl.Pos()).Line, fset.Position(funcDecl.End()).Line)
+				break
+			}
+		}
+	}
+
+	return ignored
+}
+
+// commentAnnotation returns the coverage annotation of a //-comment, allowing
+// a free-form reason after it, e.g. "//coverage:ignore unreachable".
+func commentAnnotation(text string) string {
+	if !strings.HasPrefix(text, "//") {
+		return ""
+	}
+	fields := strings.Fields(strings.TrimPrefix(text, "//"))
+	if len(fields) == 0 {
+		return ""
+	}
+	switch fields[0] {
+	case ignoreAnnotation, ignoreStartAnnotation, ignoreEndAnnotation:
+		return fields[0]
+	}
+	return ""
+}
+
+// nextCodeLine returns the first line after line that is neither blank nor a
+// line comment.
+func nextCodeLine(lines []string, line int) int {
+	for next := line + 1; next <= len(lines); next++ {
+		trimmed := strings.TrimSpace(lines[next-1])
+		if trimmed != "" && !strings.HasPrefix(trimmed, "//") {
+			return next
+		}
+	}
+	return line
+}
+
+// isIgnored reports whether line is ignored.
+func isIgnored(ignored []bool, line int) bool {
+	return line > 0 && line < len(ignored) && ignored[line]
+}
+
+// isBlockIgnored reports whether any line holding code of the block is
+// ignored, so that a block is left out as a whole when an annotation covers
+// part of its statements. Lines the block only touches, such as the line of
+// the next statement it ends on or a brace it starts after, do not count.
+func isBlockIgnored(ignored []bool, lines []string, block cover.ProfileBlock) bool {
+	if ignored == nil {
+		return false
+	}
+	for line := block.StartLine; line <= block.EndLine && line <= len(lines); line++ {
+		if !isIgnored(ignored, line) {
+			continue
+		}
+		text := lines[line-1]
+		from, to := 0, len(text)
+		if line == block.StartLine {
+			from = columnOffset(text, block.StartCol)
+		}
+		if line == block.EndLine {
+			to = columnOffset(text, block.EndCol)
+		}
+		if from < to && hasCode(text[from:to]) {
+			return true
+		}
+	}
+	return false
+}
+
+// columnOffset returns the byte offset of the 1-based column col in text.
+func columnOffset(text string, col int) int {
+	if col < 1 {
+		return 0
+	}
+	if col-1 > len(text) {
+		return len(text)
+	}
+	return col - 1
+}
+
+// hasCode reports whether a piece of a line holds more than blanks, braces
+// and a trailing line comment.
+func hasCode(text string) bool {
+	if index := strings.Index(text, "//"); index >= 0 {
+		text = text[:index]
+	}
+	return strings.Trim(text, " \t\r{}") != ""
+}
@@ -0,0 +1,156 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/tools/cover"
+)
+
+func TestBuildFileReportIgnoresBlocksByAnyLine(t *testing.T) {
+	source := `package a
+
+func A(x int) int {
+	y := x + 1
+	y *= 2 //coverage:ignore debug only
+	return y
+}
+
+func B() int {
+	return 1
+}
+`
+	root := t.TempDir()
+	sourcePath := filepath.Join(root, "a.go")
+	if err := os.WriteFile(sourcePath, []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	resolver, err := newFileResolver(root, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	profile := testProfile(sourcePath, "set", testBlock(3, 19, 7, 2, 3, 0), testBlock(9, 14, 11, 2, 1, 1))
+	file, err := buildFileReport(profile, resolver, DefaultBands)
+	if err != nil {
+		t.Fatalf("buildFileReport() error = %v", err)
+	}
+
+	if !file.Blocks[0].Ignored || file.Blocks[1].Ignored {
+		t.Errorf("block ignored = %v, %v, want true, false", file.Blocks[0].Ignored, file.Blocks[1].Ignored)
+	}
+	if file.IgnoredStmts != 3 || file.TotalStmts != 1 || file.CoveredStmts != 1 {
+		t.Errorf("statements ignored/total/covered = %d/%d/%d, want 3/1/1", file.IgnoredStmts, file.TotalStmts, file.CoveredStmts)
+	}
+	for _, number := range []int{4, 5, 6} {
+		if class := file.Lines[number-1].Class; class != "ignored" {
+			t.Errorf("line %d class = %q, want ignored", number, class)
+		}
+	}
+}
+
+func TestBuildFileReportIgnoresOnlyAnnotatedStatements(t *testing.T) {
+	source := `package a
+
+func Before(x int) int {
+	n := 1
+	//coverage:ignore-start
+	if x > 100 {
+		n = 2
+	}
+	//coverage:ignore-end
+	return n
+}
+
+func After(x int) int {
+	n := 1
+	//coverage:ignore-start
+	if x > 100 {
+		n = 2
+	}
+	//coverage:ignore-end
+	n++
+	if x > 50 {
+		n = 3
+	}
+	return n
+}
+
+func Trailing(x int) int {
+	if x < 0 {
+		return 0
+	} //coverage:ignore
+	if x > 50 {
+		x = 3 //coverage:ignore
+	}
+	return x
+}
+`
+	root := t.TempDir()
+	sourcePath := filepath.Join(root, "a.go")
+	if err := os.WriteFile(sourcePath, []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	resolver, err := newFileResolver(root, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The blocks go test -cover writes for the source.
+	tests := []struct {
+		block       cover.ProfileBlock
+		wantIgnored bool
+	}{
+		{testBlock(4, 2, 5, 1, 2, 1), false}, // ends on the ignore-start line
+		{testBlock(6, 2, 6, 13, 2, 1), true},
+		{testBlock(7, 3, 8, 1, 1, 0), true},
+		{testBlock(10, 2, 10, 10, 1, 1), false},
+		{testBlock(14, 2, 15, 1, 2, 1), false},
+		{testBlock(16, 2, 16, 13, 2, 1), true},
+		{testBlock(17, 3, 18, 1, 1, 0), true},
+		{testBlock(20, 2, 21, 12, 2, 1), false}, // starts after the ignore-end line
+		{testBlock(22, 3, 23, 1, 1, 0), false},
+		{testBlock(24, 2, 24, 10, 1, 1), false},
+		{testBlock(28, 2, 28, 11, 1, 1), false},
+		{testBlock(29, 3, 30, 1, 1, 0), false}, // ends on a line with a trailing annotation
+		{testBlock(31, 2, 31, 12, 1, 1), false},
+		{testBlock(32, 3, 33, 1, 1, 0), true},
+		{testBlock(34, 2, 34, 10, 1, 1), false},
+	}
+	blocks := make([]cover.ProfileBlock, 0, len(tests))
+	for _, test := range tests {
+		blocks = append(blocks, test.block)
+	}
+
+	file, err := buildFileReport(testProfile(sourcePath, "set", blocks...), resolver, DefaultBands)
+	if err != nil {
+		t.Fatalf("buildFileReport() error = %v", err)
+	}
+
+	for index, test := range tests {
+		block := file.Blocks[index]
+		if block.Ignored != test.wantIgnored {
+			t.Errorf("block %d.%d,%d.%d ignored = %v, want %v", block.StartLine, block.StartCol, block.EndLine, block.EndCol, block.Ignored, test.wantIgnored)
+		}
+	}
+	if file.IgnoredStmts != 7 || file.TotalStmts != 13 || file.CoveredStmts != 11 {
+		t.Errorf("statements ignored/total/covered = %d/%d/%d, want 7/13/11", file.IgnoredStmts, file.TotalStmts, file.CoveredStmts)
+	}
+}
+
+func TestBuildLineFileReportIgnoresAnnotatedLines(t *testing.T) {
+	source := "package a\n\nfunc A() {\n\tprintln() //coverage:ignore\n\tprintln()\n}\n"
+	sourcePath := filepath.Join(t.TempDir(), "a.go")
+	if err := os.WriteFile(sourcePath, []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	file := buildLineFileReport("a.go", sourcePath, "a.go", []LineHits{{Number: 4}, {Number: 5, Hits: 1}}, nil, DefaultBands)
+	if file.IgnoredStmts != 1 || file.TotalStmts != 1 || file.CoveredStmts != 1 {
+		t.Errorf("statements ignored/total/covered = %d/%d/%d, want 1/1/1", file.IgnoredStmts, file.TotalStmts, file.CoveredStmts)
+	}
+	if class := file.Lines[3].Class; class != "ignored" {
+		t.Errorf("line 4 class = %q, want ignored", class)
+	}
+}
@@ -0,0 +1,150 @@
+package report
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/cover"
+)
+
+// InputFormat identifies the coverage format of an input file.
+type InputFormat string
+
+const (
+	FormatAuto      InputFormat = ""
+	FormatGoCover   InputFormat = "go"
+	FormatLCOV      InputFormat = "lcov"
+	FormatCobertura InputFormat = "cobertura"
+)
+
+// ParseInputFormat validates a format name given on the command line.
+func ParseInputFormat(value string) (InputFormat, error) {
+	switch value {
+	case "", "auto":
+		return FormatAuto, nil
+	case string(FormatGoCover), string(FormatLCOV), string(FormatCobertura):
+		return InputFormat(value), nil
+	}
+	return FormatAuto, fmt.Errorf("unknown input format %q", value)
+}
+
+// Inputs holds parsed coverage data grouped by input format.
+type Inputs struct {
+	Profiles  []*cover.Profile
+	LCOV      []LCOVRecord
+	Cobertura CoberturaReport
+}
+
+// Formats lists the input formats that contributed data.
+func (inputs Inputs) Formats() []InputFormat {
+	formats := make([]InputFormat, 0, 3)
+	if len(inputs.Profiles) > 0 {
+		formats = append(formats, FormatGoCover)
+	}
+	if len(inputs.LCOV) > 0 {
+		formats = append(formats, FormatLCOV)
+	}
+	if len(inputs.Cobertura.Packages) > 0 {
+		formats = append(formats, FormatCobertura)
+	}
+	return formats
+}
+
+// ReadInputs parses every path and merges the results per format. With
+// FormatAuto the format of each file is detected from its extension and
+// content.
+func ReadInputs(paths []string, format InputFormat) (Inputs, error) {
+	var inputs Inputs
+	for _, path := range paths {
+		if err := inputs.read(path, format); err != nil {
+			return Inputs{}, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return inputs, nil
+}
+
+func (inputs *Inputs) read(path string, format InputFormat) error {
+	reader, closer, err := openInput(path)
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+
+	if format == FormatAuto {
+		head, _ := reader.Peek(512)
+		format = DetectFormat(path, head)
+	}
+
+	switch format {
+	case FormatLCOV:
+		records, err := ParseLCOVFromReader(reader)
+		if err != nil {
+			return err
+		}
+		inputs.LCOV = MergeLCOV(inputs.LCOV, records)
+	case FormatCobertura:
+		document, err := ParseCoberturaFromReader(reader)
+		if err != nil {
+			return err
+		}
+		inputs.Cobertura = MergeCobertura(inputs.Cobertura, document)
+	default:
+		profiles, err := ParseProfilesFromReader(reader)
+		if err != nil {
+			return err
+		}
+		inputs.Profiles, err = MergeProfiles(inputs.Profiles, profiles)
+		if err != nil {
+			return fmt.Errorf("merge: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// DetectFormat guesses the format of a coverage file from its name and the
+// first bytes of its (decompressed) content.
+func DetectFormat(name string, head []byte) InputFormat {
+	switch strings.ToLower(filepath.Ext(strings.TrimSuffix(name, ".gz"))) {
+	case ".info", ".lcov", ".dat":
+		return FormatLCOV
+	case ".xml":
+		return FormatCobertura
+	}
+
+	trimmed := bytes.TrimSpace(head)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("mode:")):
+		return FormatGoCover
+	case bytes.HasPrefix(trimmed, []byte("TN:")), bytes.HasPrefix(trimmed, []byte("SF:")):
+		return FormatLCOV
+	case bytes.HasPrefix(trimmed, []byte("<?xml")), bytes.HasPrefix(trimmed, []byte("<!DOCTYPE coverage")), bytes.HasPrefix(trimmed, []byte("<coverage")):
+		return FormatCobertura
+	}
+
+	return FormatGoCover
+}
+
+func openInput(path string) (*bufio.Reader, io.Closer, error) {
+	var file io.ReadCloser = io.NopCloser(os.Stdin)
+	if path != StdinPath {
+		opened, err := os.Open(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		file = opened
+	}
+
+	reader, err := decompress(file)
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+
+	return bufio.NewReader(reader), file, nil
+}
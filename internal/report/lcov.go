@@ -0,0 +1,233 @@
+package report
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LCOVRecord holds the line and branch data of one SF section of an LCOV
+// tracefile.
+type LCOVRecord struct {
+	SourceFile string
+	Lines      []LineHits
+	Branches   []BranchCoverage
+}
+
+// ParseLCOV parses the LCOV tracefile at path. A path of "-" reads from
+// standard input.
+func ParseLCOV(path string) ([]LCOVRecord, error) {
+	reader, closer, err := openInput(path)
+	if err != nil {
+		return nil, fmt.Errorf("parse lcov: %w", err)
+	}
+	defer closer.Close()
+
+	return ParseLCOVFromReader(reader)
+}
+
+// ParseLCOVFromReader parses LCOV tracefile records from reader. Records for
+// the same source file are merged.
+func ParseLCOVFromReader(reader io.Reader) ([]LCOVRecord, error) {
+	input, err := decompress(reader)
+	if err != nil {
+		return nil, fmt.Errorf("parse lcov: %w", err)
+	}
+
+	records := make([]LCOVRecord, 0)
+	var current *LCOVRecord
+	scanner := bufio.NewScanner(input)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	lineNumber := 0
+
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if line == "end_of_record" {
+			if current != nil {
+				records = append(records, *current)
+				current = nil
+			}
+			continue
+		}
+
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			return nil, fmt.Errorf("parse lcov: line %d: malformed record %q", lineNumber, line)
+		}
+
+		if key == "SF" {
+			if current != nil {
+				records = append(records, *current)
+			}
+			current = &LCOVRecord{SourceFile: value}
+			continue
+		}
+		if current == nil {
+			continue
+		}
+
+		switch key {
+		case "DA":
+			fields := strings.Split(value, ",")
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("parse lcov: line %d: malformed DA record %q", lineNumber, line)
+			}
+			number, err := strconv.Atoi(fields[0])
+			if err != nil {
+				return nil, fmt.Errorf("parse lcov: line %d: invalid line number: %w", lineNumber, err)
+			}
+			hits, err := parseLCOVCount(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("parse lcov: line %d: invalid hit count: %w", lineNumber, err)
+			}
+			current.Lines = append(current.Lines, LineHits{Number: number, Hits: hits})
+		case "BRDA":
+			fields := strings.Split(value, ",")
+			if len(fields) < 4 {
+				return nil, fmt.Errorf("parse lcov: line %d: malformed BRDA record %q", lineNumber, line)
+			}
+			number, err := strconv.Atoi(fields[0])
+			if err != nil {
+				return nil, fmt.Errorf("parse lcov: line %d: invalid line number: %w", lineNumber, err)
+			}
+			block, err := strconv.Atoi(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("parse lcov: line %d: invalid block number: %w", lineNumber, err)
+			}
+			taken := fields[len(fields)-1]
+			branch := BranchCoverage{
+				Line:   number,
+				Block:  block,
+				Branch: strings.Join(fields[2:len(fields)-1], ","),
+			}
+			if taken != "-" {
+				branch.Reached = true
+				branch.Taken, err = parseLCOVCount(taken)
+				if err != nil {
+					return nil, fmt.Errorf("parse lcov: line %d: invalid branch count: %w", lineNumber, err)
+				}
+			}
+			current.Branches = append(current.Branches, branch)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("parse lcov: %w", err)
+	}
+	if current != nil {
+		records = append(records, *current)
+	}
+
+	return MergeLCOV(records), nil
+}
+
+// parseLCOVCount accepts the integer counts most tools write as well as the
+// floating point counts some emit for large values.
+func parseLCOVCount(value string) (int, error) {
+	if count, err := strconv.Atoi(value); err == nil {
+		return count, nil
+	}
+	count, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
+// MergeLCOV combines LCOV records per source file, summing line and branch
+// hit counts.
+func MergeLCOV(sets ...[]LCOVRecord) []LCOVRecord {
+	type branchKey struct {
+		line   int
+		block  int
+		branch string
+	}
+	type mergedRecord struct {
+		lines    map[int]int
+		branches map[branchKey]*BranchCoverage
+	}
+
+	merged := make(map[string]*mergedRecord)
+	for _, records := range sets {
+		for _, record := range records {
+			entry := merged[record.SourceFile]
+			if entry == nil {
+				entry = &mergedRecord{lines: map[int]int{}, branches: map[branchKey]*BranchCoverage{}}
+				merged[record.SourceFile] = entry
+			}
+			for _, line := range record.Lines {
+				entry.lines[line.Number] += line.Hits
+			}
+			for _, branch := range record.Branches {
+				key := branchKey{line: branch.Line, block: branch.Block, branch: branch.Branch}
+				existing := entry.branches[key]
+				if existing == nil {
+					copied := branch
+					entry.branches[key] = &copied
+					continue
+				}
+				existing.Taken += branch.Taken
+				existing.Reached = existing.Reached || branch.Reached
+			}
+		}
+	}
+
+	result := make([]LCOVRecord, 0, len(merged))
+	for sourceFile, entry := range merged {
+		record := LCOVRecord{SourceFile: sourceFile}
+		for number, hits := range entry.lines {
+			record.Lines = append(record.Lines, LineHits{Number: number, Hits: hits})
+		}
+		sort.Slice(record.Lines, func(i, j int) bool {
+			return record.Lines[i].Number < record.Lines[j].Number
+		})
+		for _, branch := range entry.branches {
+			record.Branches = append(record.Branches, *branch)
+		}
+		sort.Slice(record.Branches, func(i, j int) bool {
+			left, right := record.Branches[i], record.Branches[j]
+			if left.Line != right.Line {
+				return left.Line < right.Line
+			}
+			if left.Block != right.Block {
+				return left.Block < right.Block
+			}
+			return left.Branch < right.Branch
+		})
+		result = append(result, record)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].SourceFile < result[j].SourceFile
+	})
+
+	return result
+}
+
+// GenerateFromLCOV builds a report from LCOV records. Every DA line counts as
+// one statement.
+func GenerateFromLCOV(records []LCOVRecord, options Options) (Report, error) {
+	resolver, err := newFileResolver(options.Root, nil, options.PathMappings)
+	if err != nil {
+		return Report{}, err
+	}
+
+	files := make([]FileReport, 0, len(records))
+	for _, record := range records {
+		sourcePath, relativePath := resolver.resolve(record.SourceFile)
+		files = append(files, buildLineFileReport(record.SourceFile, sourcePath, relativePath, record.Lines, record.Branches, options.coverageBands()))
+	}
+
+	report, err := newReport(options, files)
+	if err != nil {
+		return Report{}, err
+	}
+	report.Mode = string(FormatLCOV)
+
+	return report, nil
+}
@@ -0,0 +1,130 @@
+package report
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseLCOVFromReader(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []LCOVRecord
+		wantErr string
+	}{
+		{
+			name: "lines and branches",
+			input: strings.Join([]string{
+				"TN:unit",
+				"SF:/src/a.go",
+				"FN:3,A",
+				"FNDA:2,A",
+				"DA:3,2",
+				"DA:4,0",
+				"BRDA:3,0,0,2",
+				"BRDA:3,0,1,0",
+				"BRDA:4,1,0,-",
+				"BRF:3",
+				"BRH:1",
+				"LF:2",
+				"LH:1",
+				"end_of_record",
+			}, "\n"),
+			want: []LCOVRecord{{
+				SourceFile: "/src/a.go",
+				Lines:      []LineHits{{Number: 3, Hits: 2}, {Number: 4}},
+				Branches: []BranchCoverage{
+					{Line: 3, Block: 0, Branch: "0", Taken: 2, Reached: true},
+					{Line: 3, Block: 0, Branch: "1", Reached: true},
+					{Line: 4, Block: 1, Branch: "0"},
+				},
+			}},
+		},
+		{
+			name:  "lines only",
+			input: "SF:b.go\nDA:1,1\nDA:2,0\nend_of_record\n",
+			want:  []LCOVRecord{{SourceFile: "b.go", Lines: []LineHits{{Number: 1, Hits: 1}, {Number: 2}}}},
+		},
+		{
+			name:  "records of one file merged",
+			input: "SF:a.go\nDA:1,1\nBRDA:1,0,0,1\nend_of_record\nSF:b.go\nDA:1,0\nend_of_record\nSF:a.go\nDA:1,2\nDA:2,1\nBRDA:1,0,0,-\nend_of_record\n",
+			want: []LCOVRecord{
+				{
+					SourceFile: "a.go",
+					Lines:      []LineHits{{Number: 1, Hits: 3}, {Number: 2, Hits: 1}},
+					Branches:   []BranchCoverage{{Line: 1, Branch: "0", Taken: 1, Reached: true}},
+				},
+				{SourceFile: "b.go", Lines: []LineHits{{Number: 1}}},
+			},
+		},
+		{
+			name:  "floating point counts",
+			input: "SF:a.go\nDA:1,1.5e+10\nend_of_record\n",
+			want:  []LCOVRecord{{SourceFile: "a.go", Lines: []LineHits{{Number: 1, Hits: 15000000000}}}},
+		},
+		{
+			name:  "CRLF and missing end_of_record",
+			input: "SF:a.go\r\nDA:1,1\r\n",
+			want:  []LCOVRecord{{SourceFile: "a.go", Lines: []LineHits{{Number: 1, Hits: 1}}}},
+		},
+		{
+			name:  "records before SF",
+			input: "TN:\nDA:1,1\nSF:a.go\nDA:2,1\nend_of_record\n",
+			want:  []LCOVRecord{{SourceFile: "a.go", Lines: []LineHits{{Number: 2, Hits: 1}}}},
+		},
+		{
+			name:    "record without colon",
+			input:   "SF:a.go\nDA 1,1\n",
+			wantErr: `line 2: malformed record "DA 1,1"`,
+		},
+		{
+			name:    "short DA record",
+			input:   "SF:a.go\nDA:1\n",
+			wantErr: `line 2: malformed DA record "DA:1"`,
+		},
+		{
+			name:    "invalid DA line number",
+			input:   "SF:a.go\nDA:x,1\n",
+			wantErr: "line 2: invalid line number",
+		},
+		{
+			name:    "invalid DA hit count",
+			input:   "SF:a.go\nDA:1,many\n",
+			wantErr: "line 2: invalid hit count",
+		},
+		{
+			name:    "short BRDA record",
+			input:   "SF:a.go\nDA:1,1\nBRDA:1,0,1\n",
+			wantErr: `line 3: malformed BRDA record "BRDA:1,0,1"`,
+		},
+		{
+			name:    "invalid BRDA block",
+			input:   "SF:a.go\nBRDA:1,x,0,1\n",
+			wantErr: "line 2: invalid block number",
+		},
+		{
+			name:    "invalid BRDA count",
+			input:   "SF:a.go\nBRDA:1,0,0,x\n",
+			wantErr: "line 2: invalid branch count",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseLCOVFromReader(strings.NewReader(test.input))
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("ParseLCOVFromReader() error = %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseLCOVFromReader() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("ParseLCOVFromReader() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package report
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// LineHits is the execution count of one source line, such as an LCOV DA
+// record.
+type LineHits struct {
+	Number int
+	Hits   int
+}
+
+// BranchCoverage is the outcome of one branch on a source line, such as an
+// LCOV BRDA record.
+type BranchCoverage struct {
+	Line    int
+	Block   int
+	Branch  string
+	Taken   int
+	Reached bool
+}
+
+// buildLineFileReport builds a file report from line-based coverage formats,
+// counting every instrumented line as one statement and block.
+func buildLineFileReport(name, sourcePath, relativePath string, lineHits []LineHits, branchList []BranchCoverage, bands []Band) FileReport {
+	content, readErr := os.ReadFile(sourcePath)
+
+	var ignored []bool
+	if readErr == nil {
+		ignored = ignoredLines(sourcePath, content)
+	}
+
+	hits := make(map[int]int, len(lineHits))
+	blocks := make([]BlockCoverage, 0, len(lineHits))
+	coveredStmts := 0
+	totalStmts := 0
+	ignoredStmts := 0
+	maxHits := 0
+	for _, line := range lineHits {
+		hits[line.Number] = line.Hits
+		lineIgnored := isIgnored(ignored, line.Number)
+		blocks = append(blocks, BlockCoverage{
+			StartLine: line.Number,
+			EndLine:   line.Number,
+			NumStmt:   1,
+			Hits:      line.Hits,
+			Ignored:   lineIgnored,
+		})
+		if lineIgnored {
+			ignoredStmts++
+			continue
+		}
+		totalStmts++
+		if line.Hits > 0 {
+			coveredStmts++
+		}
+		if line.Hits > maxHits {
+			maxHits = line.Hits
+		}
+	}
+
+	branches := make(map[int][]BranchCoverage)
+	for _, branch := range branchList {
+		branches[branch.Line] = append(branches[branch.Line], branch)
+	}
+
+	coveragePercent := percent(coveredStmts, totalStmts)
+	report := FileReport{
+		Name:               name,
+		CoveredStmts:       coveredStmts,
+		TotalStmts:         totalStmts,
+		IgnoredStmts:       ignoredStmts,
+		CoveragePercent:    formatPercent(coveragePercent),
+		CoverageClass:      coverageClass(bands, coveragePercent),
+		Anchor:             sanitizeAnchor(name),
+		MaxHits:            maxHits,
+		Blocks:             blocks,
+		SourcePath:         absolutePath(sourcePath),
+		RelativeSourcePath: relativePath,
+	}
+
+	if readErr != nil {
+		report.Missing = true
+		report.MissingDescription = fmt.Sprintf("source not found at %s", sourcePath)
+		return report
+	}
+
+	report.Generated = isGenerated(content)
+
+	lines := strings.Split(string(content), "\n")
+	report.Lines = make([]LineCoverage, 0, len(lines))
+	for index, raw := range lines {
+		number := index + 1
+		className := "not-tracked"
+		count, tracked := hits[number]
+		if tracked && isIgnored(ignored, number) {
+			className = "ignored"
+		} else if tracked {
+			className = "missed"
+			if count > 0 {
+				className = "covered"
+				for _, branch := range branches[number] {
+					if branch.Taken == 0 {
+						className = "partial"
+						break
+					}
+				}
+			}
+		}
+
+		report.Lines = append(report.Lines, LineCoverage{
+			Number:   number,
+			Code:     raw,
+			Class:    className,
+			MaxHits:  hits[number],
+			SumHits:  hits[number],
+			Heat:     heatLevel(hits[number], maxHits),
+			Branches: branches[number],
+		})
+	}
+
+	return report
+}
@@ -0,0 +1,87 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+
+	"golang.org/x/tools/cover"
+)
+
+// MergeProfiles combines several parsed coverprofiles into one profile per
+// source file. Blocks are merged as a boolean union in set mode and by summing
+// counts in count and atomic modes.
+func MergeProfiles(sets ...[]*cover.Profile) ([]*cover.Profile, error) {
+	merged := make(map[string]*cover.Profile)
+	mode := ""
+
+	for _, profiles := range sets {
+		for _, profile := range profiles {
+			if mode == "" {
+				mode = profile.Mode
+			} else if profile.Mode != mode {
+				return nil, fmt.Errorf("conflicting cover modes %q and %q for %s", mode, profile.Mode, profile.FileName)
+			}
+
+			existing := merged[profile.FileName]
+			if existing == nil {
+				merged[profile.FileName] = copyProfile(profile)
+				continue
+			}
+			if err := mergeBlocks(existing, profile); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	result := make([]*cover.Profile, 0, len(merged))
+	for _, profile := range merged {
+		result = append(result, profile)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].FileName < result[j].FileName
+	})
+
+	return result, nil
+}
+
+func copyProfile(profile *cover.Profile) *cover.Profile {
+	blocks := make([]cover.ProfileBlock, len(profile.Blocks))
+	copy(blocks, profile.Blocks)
+	return &cover.Profile{
+		FileName: profile.FileName,
+		Mode:     profile.Mode,
+		Blocks:   blocks,
+	}
+}
+
+func mergeBlocks(target, source *cover.Profile) error {
+	if len(target.Blocks) != len(source.Blocks) {
+		return fmt.Errorf("block layout mismatch for %s: %d blocks vs %d blocks", target.FileName, len(target.Blocks), len(source.Blocks))
+	}
+
+	for index := range target.Blocks {
+		block := &target.Blocks[index]
+		other := source.Blocks[index]
+		if block.StartLine != other.StartLine ||
+			block.StartCol != other.StartCol ||
+			block.EndLine != other.EndLine ||
+			block.EndCol != other.EndCol ||
+			block.NumStmt != other.NumStmt {
+			return fmt.Errorf("block layout mismatch for %s: %s vs %s", target.FileName, formatBlock(*block), formatBlock(other))
+		}
+
+		if target.Mode == "set" {
+			if other.Count > 0 {
+				block.Count = 1
+			}
+		} else {
+			block.Count += other.Count
+		}
+	}
+
+	return nil
+}
+
+func formatBlock(block cover.ProfileBlock) string {
+	return fmt.Sprintf("%d.%d,%d.%d (%d stmts)", block.StartLine, block.StartCol, block.EndLine, block.EndCol, block.NumStmt)
+}
@@ -0,0 +1,155 @@
+package report
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/cover"
+)
+
+func testBlock(startLine, startCol, endLine, endCol, numStmt, count int) cover.ProfileBlock {
+	return cover.ProfileBlock{
+		StartLine: startLine,
+		StartCol:  startCol,
+		EndLine:   endLine,
+		EndCol:    endCol,
+		NumStmt:   numStmt,
+		Count:     count,
+	}
+}
+
+func testProfile(fileName, mode string, blocks ...cover.ProfileBlock) *cover.Profile {
+	return &cover.Profile{FileName: fileName, Mode: mode, Blocks: blocks}
+}
+
+func TestMergeProfiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		sets    [][]*cover.Profile
+		want    []*cover.Profile
+		wantErr string
+	}{
+		{
+			name: "set mode is a union",
+			sets: [][]*cover.Profile{
+				{testProfile("a.go", "set", testBlock(1, 1, 2, 2, 1, 1), testBlock(3, 1, 4, 2, 1, 0), testBlock(5, 1, 6, 2, 1, 0))},
+				{testProfile("a.go", "set", testBlock(1, 1, 2, 2, 1, 0), testBlock(3, 1, 4, 2, 1, 1), testBlock(5, 1, 6, 2, 1, 0))},
+			},
+			want: []*cover.Profile{
+				testProfile("a.go", "set", testBlock(1, 1, 2, 2, 1, 1), testBlock(3, 1, 4, 2, 1, 1), testBlock(5, 1, 6, 2, 1, 0)),
+			},
+		},
+		{
+			name: "set mode stays boolean",
+			sets: [][]*cover.Profile{
+				{testProfile("a.go", "set", testBlock(1, 1, 2, 2, 1, 1))},
+				{testProfile("a.go", "set", testBlock(1, 1, 2, 2, 1, 1))},
+			},
+			want: []*cover.Profile{testProfile("a.go", "set", testBlock(1, 1, 2, 2, 1, 1))},
+		},
+		{
+			name: "count mode sums",
+			sets: [][]*cover.Profile{
+				{testProfile("a.go", "count", testBlock(1, 1, 2, 2, 1, 3), testBlock(3, 1, 4, 2, 2, 0))},
+				{testProfile("a.go", "count", testBlock(1, 1, 2, 2, 1, 4), testBlock(3, 1, 4, 2, 2, 0))},
+			},
+			want: []*cover.Profile{testProfile("a.go", "count", testBlock(1, 1, 2, 2, 1, 7), testBlock(3, 1, 4, 2, 2, 0))},
+		},
+		{
+			name: "atomic mode sums",
+			sets: [][]*cover.Profile{
+				{testProfile("a.go", "atomic", testBlock(1, 1, 2, 2, 1, 10))},
+				{testProfile("a.go", "atomic", testBlock(1, 1, 2, 2, 1, 5))},
+				{testProfile("a.go", "atomic", testBlock(1, 1, 2, 2, 1, 1))},
+			},
+			want: []*cover.Profile{testProfile("a.go", "atomic", testBlock(1, 1, 2, 2, 1, 16))},
+		},
+		{
+			name: "distinct files are kept and sorted",
+			sets: [][]*cover.Profile{
+				{testProfile("b.go", "set", testBlock(1, 1, 2, 2, 1, 1))},
+				{testProfile("a.go", "set", testBlock(1, 1, 2, 2, 1, 0))},
+			},
+			want: []*cover.Profile{
+				testProfile("a.go", "set", testBlock(1, 1, 2, 2, 1, 0)),
+				testProfile("b.go", "set", testBlock(1, 1, 2, 2, 1, 1)),
+			},
+		},
+		{
+			name: "conflicting modes",
+			sets: [][]*cover.Profile{
+				{testProfile("a.go", "set", testBlock(1, 1, 2, 2, 1, 1))},
+				{testProfile("a.go", "count", testBlock(1, 1, 2, 2, 1, 1))},
+			},
+			wantErr: `conflicting cover modes "set" and "count" for a.go`,
+		},
+		{
+			name: "different block counts",
+			sets: [][]*cover.Profile{
+				{testProfile("a.go", "set", testBlock(1, 1, 2, 2, 1, 1))},
+				{testProfile("a.go", "set", testBlock(1, 1, 2, 2, 1, 1), testBlock(3, 1, 4, 2, 1, 1))},
+			},
+			wantErr: "block layout mismatch for a.go: 1 blocks vs 2 blocks",
+		},
+		{
+			name: "different block positions",
+			sets: [][]*cover.Profile{
+				{testProfile("a.go", "set", testBlock(1, 1, 2, 2, 1, 1))},
+				{testProfile("a.go", "set", testBlock(1, 1, 2, 9, 1, 1))},
+			},
+			wantErr: "block layout mismatch for a.go: 1.1,2.2 (1 stmts) vs 1.1,2.9 (1 stmts)",
+		},
+		{
+			name: "different statement counts",
+			sets: [][]*cover.Profile{
+				{testProfile("a.go", "count", testBlock(1, 1, 2, 2, 1, 1))},
+				{testProfile("a.go", "count", testBlock(1, 1, 2, 2, 2, 1))},
+			},
+			wantErr: "block layout mismatch for a.go: 1.1,2.2 (1 stmts) vs 1.1,2.2 (2 stmts)",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := MergeProfiles(test.sets...)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("MergeProfiles() error = %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("MergeProfiles() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("MergeProfiles() = %s, want %s", formatProfiles(got), formatProfiles(test.want))
+			}
+		})
+	}
+}
+
+func TestMergeProfilesDoesNotModifyInput(t *testing.T) {
+	first := testProfile("a.go", "count", testBlock(1, 1, 2, 2, 1, 1))
+	second := testProfile("a.go", "count", testBlock(1, 1, 2, 2, 1, 2))
+
+	if _, err := MergeProfiles([]*cover.Profile{first}, []*cover.Profile{second}); err != nil {
+		t.Fatalf("MergeProfiles() error = %v", err)
+	}
+	if first.Blocks[0].Count != 1 || second.Blocks[0].Count != 2 {
+		t.Errorf("MergeProfiles() changed its input counts to %d and %d", first.Blocks[0].Count, second.Blocks[0].Count)
+	}
+}
+
+func formatProfiles(profiles []*cover.Profile) string {
+	parts := make([]string, 0, len(profiles))
+	for _, item := range profiles {
+		blocks := make([]string, 0, len(item.Blocks))
+		for _, block := range item.Blocks {
+			blocks = append(blocks, formatBlock(block)+"="+strconv.Itoa(block.Count))
+		}
+		parts = append(parts, item.FileName+"("+item.Mode+"): "+strings.Join(blocks, ", "))
+	}
+	return "[" + strings.Join(parts, "; ") + "]"
+}
@@ -1,16 +1,142 @@
 package report
 
 import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
 	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
 
 	"golang.org/x/tools/cover"
 )
 
+// StdinPath is the profile path that selects standard input.
+const StdinPath = "-"
+
+// ParseProfiles parses the coverprofile at path. A path of "-" reads from
+// standard input, and gzip-compressed profiles are decompressed transparently.
 func ParseProfiles(path string) ([]*cover.Profile, error) {
-	profiles, err := cover.ParseProfiles(path)
+	if path == StdinPath {
+		return ParseProfilesFromReader(os.Stdin)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("parse profile: %w", err)
+	}
+	defer file.Close()
+
+	return ParseProfilesFromReader(file)
+}
+
+// ParseProfilesFromReader parses a coverprofile from reader, decompressing it
+// first when it starts with the gzip magic bytes. Lines before the mode line
+// and the result lines of `go test` are skipped, so `go test` output
+// interleaved with -coverprofile=/dev/stdout is accepted; any other line is an
+// error.
+func ParseProfilesFromReader(reader io.Reader) ([]*cover.Profile, error) {
+	input, err := decompress(reader)
+	if err != nil {
+		return nil, fmt.Errorf("parse profile: %w", err)
+	}
+
+	filtered, err := filterProfileLines(input)
+	if err != nil {
+		return nil, fmt.Errorf("parse profile: %w", err)
+	}
+
+	profiles, err := cover.ParseProfilesFromReader(filtered)
 	if err != nil {
 		return nil, fmt.Errorf("parse profile: %w", err)
 	}
 
 	return profiles, nil
 }
+
+// ParseProfileFiles parses every coverprofile in paths and merges them into a
+// single set of profiles.
+func ParseProfileFiles(paths []string) ([]*cover.Profile, error) {
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no profiles given")
+	}
+
+	var merged []*cover.Profile
+	for _, path := range paths {
+		profiles, err := ParseProfiles(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		merged, err = MergeProfiles(merged, profiles)
+		if err != nil {
+			return nil, fmt.Errorf("merge %s: %w", path, err)
+		}
+	}
+
+	return merged, nil
+}
+
+var profileLinePattern = regexp.MustCompile(`^.+:[0-9]+\.[0-9]+,[0-9]+\.[0-9]+ [0-9]+ [0-9]+$`)
+
+// goTestOutputPattern matches the lines `go test` prints next to a profile
+// written to stdout: package and test results, coverage summaries and the
+// log lines of tests.
+var goTestOutputPattern = regexp.MustCompile(`^(\s*|PASS|FAIL|(ok|FAIL|\?)\s.*|\s*(---|===) .*|\s*coverage: .*|\s*\S+\s+coverage: .*|\s+\S+_test\.go:[0-9]+: .*)$`)
+
+func filterProfileLines(reader io.Reader) (io.Reader, error) {
+	var filtered bytes.Buffer
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	mode := ""
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.HasPrefix(line, "mode: ") {
+			if mode == "" {
+				mode = line
+				filtered.WriteString(line)
+				filtered.WriteByte('\n')
+			} else if line != mode {
+				return nil, fmt.Errorf("conflicting mode lines %q and %q", mode, line)
+			}
+			continue
+		}
+		if mode == "" || goTestOutputPattern.MatchString(line) {
+			continue
+		}
+		if !profileLinePattern.MatchString(line) {
+			return nil, fmt.Errorf("line %d: malformed block line %q", lineNumber, line)
+		}
+		filtered.WriteString(line)
+		filtered.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if mode == "" {
+		return nil, fmt.Errorf("missing mode line")
+	}
+
+	return &filtered, nil
+}
+
+var gzipMagic = []byte{0x1f, 0x8b}
+
+func decompress(reader io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(reader)
+	magic, err := buffered.Peek(len(gzipMagic))
+	if err != nil || magic[0] != gzipMagic[0] || magic[1] != gzipMagic[1] {
+		return buffered, nil
+	}
+
+	gzipReader, err := gzip.NewReader(buffered)
+	if err != nil {
+		return nil, fmt.Errorf("open gzip: %w", err)
+	}
+
+	return gzipReader, nil
+}
@@ -0,0 +1,120 @@
+package report
+
+import (
+	"bytes"
+	"compress/gzip"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/cover"
+)
+
+func TestParseProfilesFromReader(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []*cover.Profile
+		wantErr string
+	}{
+		{
+			name:  "plain profile",
+			input: "mode: count\na.go:1.1,2.2 1 3\na.go:3.1,4.2 2 0\n",
+			want:  []*cover.Profile{testProfile("a.go", "count", testBlock(1, 1, 2, 2, 1, 3), testBlock(3, 1, 4, 2, 2, 0))},
+		},
+		{
+			name:  "CRLF line endings",
+			input: "mode: set\r\na.go:1.1,2.2 1 1\r\n",
+			want:  []*cover.Profile{testProfile("a.go", "set", testBlock(1, 1, 2, 2, 1, 1))},
+		},
+		{
+			name:  "lines before the mode line",
+			input: "building...\nwarning: something\nmode: set\na.go:1.1,2.2 1 1\n",
+			want:  []*cover.Profile{testProfile("a.go", "set", testBlock(1, 1, 2, 2, 1, 1))},
+		},
+		{
+			name: "go test output",
+			input: strings.Join([]string{
+				"mode: set",
+				"=== RUN   TestA",
+				"    a_test.go:12: logged",
+				"--- PASS: TestA (0.00s)",
+				"    --- PASS: TestA/sub (0.00s)",
+				"PASS",
+				"coverage: 50.0% of statements",
+				"a.go:1.1,2.2 1 1",
+				"ok  \texample.com/a\t0.01s\tcoverage: 50.0% of statements",
+				"\texample.com/b\t\tcoverage: 0.0% of statements",
+				"?   \texample.com/c\t[no test files]",
+				"--- FAIL: TestB (0.00s)",
+				"FAIL",
+				"FAIL\texample.com/d\t0.01s",
+				"mode: set",
+				"",
+				"b.go:1.1,2.2 1 0",
+			}, "\n"),
+			want: []*cover.Profile{
+				testProfile("a.go", "set", testBlock(1, 1, 2, 2, 1, 1)),
+				testProfile("b.go", "set", testBlock(1, 1, 2, 2, 1, 0)),
+			},
+		},
+		{
+			name:    "missing mode line",
+			input:   "a.go:1.1,2.2 1 1\n",
+			wantErr: "missing mode line",
+		},
+		{
+			name:    "conflicting mode lines",
+			input:   "mode: set\na.go:1.1,2.2 1 1\nmode: count\n",
+			wantErr: `conflicting mode lines "mode: set" and "mode: count"`,
+		},
+		{
+			name:    "truncated block line",
+			input:   "mode: set\na.go:1.1,2.2 1 1\na.go:3.1,4.2 1\n",
+			wantErr: `line 3: malformed block line "a.go:3.1,4.2 1"`,
+		},
+		{
+			name:    "unknown output",
+			input:   "mode: set\npanic: runtime error\na.go:1.1,2.2 1 1\n",
+			wantErr: `line 2: malformed block line "panic: runtime error"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseProfilesFromReader(strings.NewReader(test.input))
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("ParseProfilesFromReader() error = %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseProfilesFromReader() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("ParseProfilesFromReader() = %s, want %s", formatProfiles(got), formatProfiles(test.want))
+			}
+		})
+	}
+}
+
+func TestParseProfilesFromReaderGzip(t *testing.T) {
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	if _, err := writer.Write([]byte("mode: count\na.go:1.1,2.2 1 5\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ParseProfilesFromReader(&compressed)
+	if err != nil {
+		t.Fatalf("ParseProfilesFromReader() error = %v", err)
+	}
+	want := []*cover.Profile{testProfile("a.go", "count", testBlock(1, 1, 2, 2, 1, 5))}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseProfilesFromReader() = %s, want %s", formatProfiles(got), formatProfiles(want))
+	}
+}
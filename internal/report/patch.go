@@ -0,0 +1,240 @@
+package report
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ChangedLines maps the slash-separated file paths of a unified diff to the
+// line numbers the diff adds or modifies in the new version of each file.
+type ChangedLines map[string][]int
+
+// PatchCoverage is the coverage of the tracked lines changed by a diff.
+type PatchCoverage struct {
+	Base            string
+	CoveredLines    int
+	TotalLines      int
+	CoveragePercent string
+	CoverageClass   string
+	Files           []PatchFile
+}
+
+// PatchFile is the patch coverage of one file with tracked changed lines.
+type PatchFile struct {
+	Name            string
+	Anchor          string
+	CoveredLines    int
+	TotalLines      int
+	CoveragePercent string
+	CoverageClass   string
+	UncoveredLines  string
+}
+
+// Percent returns the share of tracked changed lines that are covered.
+func (patch PatchCoverage) Percent() float64 {
+	return percent(patch.CoveredLines, patch.TotalLines)
+}
+
+// Percent returns the share of tracked changed lines that are covered.
+func (file PatchFile) Percent() float64 {
+	return percent(file.CoveredLines, file.TotalLines)
+}
+
+var hunkPattern = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,(\d+))? @@`)
+
+// ParseDiff parses the unified diff file at path. A path of "-" reads from
+// standard input.
+func ParseDiff(path string) (ChangedLines, error) {
+	reader, closer, err := openInput(path)
+	if err != nil {
+		return nil, fmt.Errorf("parse diff: %w", err)
+	}
+	defer closer.Close()
+
+	return ParseDiffFromReader(reader)
+}
+
+// ParseDiffFromReader parses a unified diff, as written by `git diff` or
+// `diff -u`, from reader. Deleted files are skipped.
+func ParseDiffFromReader(reader io.Reader) (ChangedLines, error) {
+	changes := make(ChangedLines)
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	current := ""
+	newLine := 0
+	remaining := 0
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+
+		if remaining > 0 {
+			switch {
+			case strings.HasPrefix(line, "+"):
+				changes[current] = append(changes[current], newLine)
+				newLine++
+				remaining--
+			case strings.HasPrefix(line, " "), line == "":
+				newLine++
+				remaining--
+			}
+			// Removed lines and "\ No newline at end of file" markers do not
+			// advance the new file.
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "+++ "):
+			name, err := diffPath(strings.TrimPrefix(line, "+++ "))
+			if err != nil {
+				return nil, fmt.Errorf("parse diff: line %d: %w", lineNumber, err)
+			}
+			current = name
+		case strings.HasPrefix(line, "@@ "):
+			match := hunkPattern.FindStringSubmatch(line)
+			if match == nil {
+				return nil, fmt.Errorf("parse diff: line %d: malformed hunk header %q", lineNumber, line)
+			}
+			newLine, _ = strconv.Atoi(match[1])
+			remaining = 1
+			if match[2] != "" {
+				remaining, _ = strconv.Atoi(match[2])
+			}
+			if current == "" {
+				return nil, fmt.Errorf("parse diff: line %d: hunk without file header", lineNumber)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("parse diff: %w", err)
+	}
+
+	delete(changes, "/dev/null")
+	return changes, nil
+}
+
+// diffPath returns the cleaned file path of a "+++" header without its
+// timestamp and "b/" prefix.
+func diffPath(header string) (string, error) {
+	name := header
+	if strings.HasPrefix(name, `"`) {
+		end := strings.LastIndex(name, `"`)
+		unquoted, err := strconv.Unquote(name[:end+1])
+		if err != nil {
+			return "", fmt.Errorf("invalid file name %s: %w", header, err)
+		}
+		name = unquoted
+	} else if index := strings.IndexByte(name, '\t'); index >= 0 {
+		name = name[:index]
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "/dev/null" {
+		return name, nil
+	}
+	return path.Clean(strings.TrimPrefix(name, "b/")), nil
+}
+
+// GitChangedLines returns the lines changed since the merge base of base and
+// HEAD in the git repository containing root, including uncommitted changes
+// to tracked files. Paths are relative to root, and changes outside root are
+// left out.
+func GitChangedLines(root, base string) (ChangedLines, error) {
+	absRoot := absoluteRoot(root)
+	mergeBase, err := runCommand(absRoot, "git", "merge-base", base, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+
+	diff, err := runCommand(absRoot, "git", "diff", "--no-color", "--no-ext-diff", "--unified=0", "--relative", strings.TrimSpace(string(mergeBase)))
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseDiffFromReader(bytes.NewReader(diff))
+}
+
+// ApplyPatch marks the changed lines of every report file and sets
+// report.Patch to the coverage of the tracked ones. base describes where the
+// changes came from.
+func ApplyPatch(report *Report, changes ChangedLines, base string) {
+	patch := &PatchCoverage{Base: base}
+
+	for index := range report.Files {
+		file := &report.Files[index]
+		numbers := changedLinesOf(file, changes)
+		if len(numbers) == 0 || file.Missing {
+			continue
+		}
+
+		patchFile := PatchFile{Name: file.Name, Anchor: file.Anchor}
+		uncovered := make([]int, 0)
+		for _, number := range numbers {
+			if number < 1 || number > len(file.Lines) {
+				continue
+			}
+			line := &file.Lines[number-1]
+			line.Changed = true
+			file.Changed = true
+
+			switch line.Class {
+			case "covered", "partial":
+				patchFile.CoveredLines++
+				patchFile.TotalLines++
+			case "missed":
+				patchFile.TotalLines++
+				uncovered = append(uncovered, number)
+			}
+		}
+		if patchFile.TotalLines == 0 {
+			continue
+		}
+
+		coveragePercent := percent(patchFile.CoveredLines, patchFile.TotalLines)
+		patchFile.CoveragePercent = formatPercent(coveragePercent)
+		patchFile.CoverageClass = coverageClass(report.Bands, coveragePercent)
+		patchFile.UncoveredLines = formatLineNumbers(uncovered)
+		patch.CoveredLines += patchFile.CoveredLines
+		patch.TotalLines += patchFile.TotalLines
+		patch.Files = append(patch.Files, patchFile)
+	}
+
+	coveragePercent := percent(patch.CoveredLines, patch.TotalLines)
+	patch.CoveragePercent = formatPercent(coveragePercent)
+	patch.CoverageClass = coverageClass(report.Bands, coveragePercent)
+	report.Patch = patch
+}
+
+// changedLinesOf looks up the changed lines of file by its slash-separated
+// path relative to the root. Other paths never match, so that files with the
+// same name in different directories do not get each other's changes.
+func changedLinesOf(file *FileReport, changes ChangedLines) []int {
+	return changes[filepath.ToSlash(file.RelativeSourcePath)]
+}
+
+// formatLineNumbers collapses sorted line numbers into ranges such as
+// "3-5, 9".
+func formatLineNumbers(numbers []int) string {
+	parts := make([]string, 0, len(numbers))
+	for start := 0; start < len(numbers); {
+		end := start
+		for end+1 < len(numbers) && numbers[end+1] == numbers[end]+1 {
+			end++
+		}
+		if end == start {
+			parts = append(parts, strconv.Itoa(numbers[start]))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d-%d", numbers[start], numbers[end]))
+		}
+		start = end + 1
+	}
+	return strings.Join(parts, ", ")
+}
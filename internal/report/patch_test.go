@@ -0,0 +1,153 @@
+package report
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseDiffFromReader(t *testing.T) {
+	tests := []struct {
+		name    string
+		diff    string
+		want    ChangedLines
+		wantErr string
+	}{
+		{
+			name: "modified file",
+			diff: `diff --git a/a.go b/a.go
+index 1111111..2222222 100644
+--- a/a.go
++++ b/a.go
+@@ -1,4 +1,5 @@
+ package a
+-var x = 1
++var x = 2
++var y = 3
+
+ func A() {}
+`,
+			want: ChangedLines{"a.go": {2, 3}},
+		},
+		{
+			name: "new and deleted files",
+			diff: `diff --git a/new.go b/new.go
+new file mode 100644
+--- /dev/null
++++ b/new.go
+@@ -0,0 +1,2 @@
++package a
++func New() {}
+diff --git a/old.go b/old.go
+deleted file mode 100644
+--- a/old.go
++++ /dev/null
+@@ -1,2 +0,0 @@
+-package a
+-func Old() {}
+`,
+			want: ChangedLines{"new.go": {1, 2}},
+		},
+		{
+			name: "renamed file",
+			diff: `diff --git a/old/name.go b/new/name.go
+similarity index 90%
+rename from old/name.go
+rename to new/name.go
+--- a/old/name.go
++++ b/new/name.go
+@@ -3 +3 @@ func A() {
+-	return 1
++	return 2
+diff --git a/same.go b/moved.go
+similarity index 100%
+rename from same.go
+rename to moved.go
+`,
+			want: ChangedLines{"new/name.go": {3}},
+		},
+		{
+			name: "zero context hunks",
+			diff: `--- a/a.go
++++ b/a.go
+@@ -3,0 +4,2 @@ func A() {
++	x++
++	y++
+@@ -10 +11,0 @@ func B() {
+-	z++
+@@ -20 +20 @@ func C() {
+-	return 1
++	return 2
+`,
+			want: ChangedLines{"a.go": {4, 5, 20}},
+		},
+		{
+			name: "CRLF line endings",
+			diff: "--- a/a.go\r\n+++ b/a.go\r\n@@ -1,3 +1,3 @@\r\n package a\r\n\r\n-var x = 1\r\n+var x = 2\r\n",
+			want: ChangedLines{"a.go": {3}},
+		},
+		{
+			name: "diff -u with timestamps and quoted names",
+			diff: "--- ./a.go\t2024-01-01 00:00:00\n+++ ./a.go\t2024-01-02 00:00:00\n@@ -1 +1 @@\n-x\n+y\n" +
+				"--- \"a/sp ace.go\"\n+++ \"b/sp ace.go\"\n@@ -1 +1 @@\n-x\n+y\n",
+			want: ChangedLines{"a.go": {1}, "sp ace.go": {1}},
+		},
+		{
+			name:    "malformed hunk header",
+			diff:    "--- a/a.go\n+++ b/a.go\n@@ -1 +x @@\n",
+			wantErr: `line 3: malformed hunk header "@@ -1 +x @@"`,
+		},
+		{
+			name:    "hunk without file header",
+			diff:    "@@ -1 +1 @@\n-x\n+y\n",
+			wantErr: "line 1: hunk without file header",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseDiffFromReader(strings.NewReader(test.diff))
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("ParseDiffFromReader() error = %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDiffFromReader() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("ParseDiffFromReader() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestChangedLinesOf(t *testing.T) {
+	changes := ChangedLines{
+		"main.go":            {1},
+		"cmd/x/main.go":      {2},
+		"internal/a/util.go": {3},
+		"internal/b/util.go": {4},
+	}
+
+	tests := []struct {
+		relative string
+		want     []int
+	}{
+		{relative: "main.go", want: []int{1}},
+		{relative: "cmd/x/main.go", want: []int{2}},
+		{relative: "internal/a/util.go", want: []int{3}},
+		{relative: "internal/b/util.go", want: []int{4}},
+		{relative: "cmd/y/main.go"},
+		{relative: "util.go"},
+		{relative: "/elsewhere/internal/a/util.go"},
+	}
+
+	for _, test := range tests {
+		file := &FileReport{Name: "example.com/m/" + test.relative, RelativeSourcePath: test.relative}
+		if got := changedLinesOf(file, changes); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("changedLinesOf(%q) = %v, want %v", test.relative, got, test.want)
+		}
+	}
+}
@@ -2,6 +2,8 @@ package report
 
 import (
 	"fmt"
+	"io"
+	"math"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -12,17 +14,44 @@ import (
 	"golang.org/x/tools/cover"
 )
 
+// Report is the coverage of a set of source files. Mode is the cover mode of
+// Go profiles (set, count or atomic) or the input format for line-based inputs.
+// Root is the absolute directory file paths are relative to.
 type Report struct {
 	Title                string
+	Root                 string
 	GeneratedAt          string
 	TotalCoveragePercent string
 	TotalCoverageClass   string
+	Thresholds           Thresholds
+	BelowThreshold       bool
 	CoveredStmts         int
 	TotalStmts           int
+	IgnoredStmts         int
 	TotalFiles           int
 	MissingFiles         int
+	UntestedFiles        int
+	Mode                 string
+	Bands                []Band
+	CoverData            *CoverDirSummary
+	Patch                *PatchCoverage
+	Comparison           *Comparison
+	Trend                *Trend
 	Tree                 []TreeNode
 	Files                []FileReport
+	Excluded             []ExcludedFile
+	LeastCovered         []FunctionSummary
+}
+
+// HasHitCounts reports whether line hit counts carry execution frequencies,
+// which is not the case for profiles recorded with -covermode=set.
+func (report Report) HasHitCounts() bool {
+	return report.Mode != "" && report.Mode != "set"
+}
+
+// Percent returns the total statement coverage.
+func (report Report) Percent() float64 {
+	return percent(report.CoveredStmts, report.TotalStmts)
 }
 
 type TreeNode struct {
@@ -35,60 +64,236 @@ type TreeNode struct {
 	CoveredStmts    int
 	TotalStmts      int
 	IsDir           bool
+	Untested        bool
+	BelowThreshold  bool
+	Delta           *Delta
+	Trend           *Trend
 	Children        []TreeNode
 }
 
+// Percent returns the statement coverage of the file or directory.
+func (node TreeNode) Percent() float64 {
+	return percent(node.CoveredStmts, node.TotalStmts)
+}
+
+// Deleted reports whether the node only exists in the base of a comparison.
+func (node TreeNode) Deleted() bool {
+	return node.Delta != nil && node.Delta.Status == DeltaDeleted
+}
+
 type FileReport struct {
 	Name               string
 	CoveragePercent    string
 	CoverageClass      string
 	CoveredStmts       int
 	TotalStmts         int
+	IgnoredStmts       int
 	Anchor             string
 	Lines              []LineCoverage
+	Blocks             []BlockCoverage
+	Functions          []FunctionCoverage
+	MaxHits            int
 	Missing            bool
 	MissingDescription string
+	Untested           bool
+	Generated          bool
+	BelowThreshold     bool
+	Changed            bool
+	Delta              *Delta
+	SourcePath         string
 	RelativeSourcePath string
 }
 
+// Percent returns the statement coverage of the file.
+func (file FileReport) Percent() float64 {
+	return percent(file.CoveredStmts, file.TotalStmts)
+}
+
+// UncoveredLines returns the ranges of lines with statements that never ran,
+// such as "3-5, 9". Partially covered lines are not included.
+func (file FileReport) UncoveredLines() string {
+	numbers := make([]int, 0)
+	for _, line := range file.Lines {
+		if line.Class == "missed" {
+			numbers = append(numbers, line.Number)
+		}
+	}
+	return formatLineNumbers(numbers)
+}
+
+// LineCoverage is the state of one source line. On partial lines, Missed
+// holds the columns of the blocks that never ran.
 type LineCoverage struct {
-	Number int
-	Code   string
-	Class  string
-	Ranges string
+	Number   int
+	Code     string
+	Class    string
+	Missed   []ColumnRange
+	MaxHits  int
+	SumHits  int
+	Heat     int
+	Changed  bool
+	Branches []BranchCoverage
+}
+
+// BlockCoverage is a profile block with its execution count. Ignored blocks
+// were excluded by a //coverage:ignore annotation.
+type BlockCoverage struct {
+	StartLine int
+	StartCol  int
+	EndLine   int
+	EndCol    int
+	NumStmt   int
+	Hits      int
+	Ignored   bool
 }
 
-func Generate(profilePath, root, title string) (Report, error) {
+// BranchSummary describes how many of the line's branches were taken, or
+// returns an empty string when the line has no branch data.
+func (line LineCoverage) BranchSummary() string {
+	if len(line.Branches) == 0 {
+		return ""
+	}
+	taken := 0
+	for _, branch := range line.Branches {
+		if branch.Taken > 0 {
+			taken++
+		}
+	}
+	return fmt.Sprintf("%d/%d branches taken", taken, len(line.Branches))
+}
+
+// Options controls how a report is generated from coverage data.
+type Options struct {
+	// Root is the directory used to resolve source file paths.
+	Root  string
+	Title string
+	// IncludeUntested adds the packages matched by UntestedPattern that are
+	// missing from the profiles as fully uncovered files.
+	IncludeUntested bool
+	UntestedPattern string
+	// Include and Exclude are glob patterns, or regular expressions prefixed
+	// with RegexPrefix, matched against file and package paths.
+	Include []string
+	Exclude []string
+	// IncludeGenerated keeps files with a "Code generated ... DO NOT EDIT."
+	// header, which are excluded by default.
+	IncludeGenerated bool
+	// PathMappings rewrite file names before they are resolved.
+	PathMappings []PathMapping
+	// Bands are the coverage classes as returned by NormalizeBands, highest
+	// minimum first. DefaultBands are used when empty.
+	Bands []Band
+}
+
+func Generate(profilePath string, options Options) (Report, error) {
 	profiles, err := ParseProfiles(profilePath)
 	if err != nil {
 		return Report{}, err
 	}
 
-	resolver, err := newFileResolver(root, profiles)
+	return GenerateFromProfiles(profiles, options)
+}
+
+// GenerateFromReader builds a report from a coverprofile read from reader.
+func GenerateFromReader(reader io.Reader, options Options) (Report, error) {
+	profiles, err := ParseProfilesFromReader(reader)
 	if err != nil {
 		return Report{}, err
 	}
 
-	report := Report{
-		Title:       title,
-		GeneratedAt: time.Now().Format("2006-01-02 15:04:05"),
+	return GenerateFromProfiles(profiles, options)
+}
+
+// GenerateFromProfiles builds a report from already parsed profiles, such as
+// the merged result of MergeProfiles.
+func GenerateFromProfiles(profiles []*cover.Profile, options Options) (Report, error) {
+	mode := ""
+	if len(profiles) > 0 {
+		mode = profiles[0].Mode
 	}
 
-	totalCovered := 0
-	totalStmts := 0
+	fileNames := make([]string, 0, len(profiles))
+	for _, profile := range profiles {
+		fileNames = append(fileNames, profile.FileName)
+	}
+
+	resolver, err := newFileResolver(options.Root, fileNames, options.PathMappings)
+	if err != nil {
+		return Report{}, err
+	}
 
+	untested := make(map[string]bool)
+	if options.IncludeUntested {
+		untestedProfiles, err := findUntestedProfiles(resolver, options.UntestedPattern, profiles, mode)
+		if err != nil {
+			return Report{}, err
+		}
+		for _, profile := range untestedProfiles {
+			untested[profile.FileName] = true
+		}
+		profiles = append(profiles[:len(profiles):len(profiles)], untestedProfiles...)
+	}
+
+	files := make([]FileReport, 0, len(profiles))
 	for _, profile := range profiles {
-		fileReport, err := buildFileReport(profile, resolver)
+		fileReport, err := buildFileReport(profile, resolver, options.coverageBands())
 		if err != nil {
 			return Report{}, err
 		}
+		fileReport.Untested = untested[profile.FileName]
+		files = append(files, fileReport)
+	}
+
+	report, err := newReport(options, files)
+	if err != nil {
+		return Report{}, err
+	}
+	report.Mode = mode
+
+	return report, nil
+}
+
+func newReport(options Options, files []FileReport) (Report, error) {
+	filter, err := newFileFilter(options)
+	if err != nil {
+		return Report{}, err
+	}
+
+	report := Report{
+		Title:       options.Title,
+		Root:        absoluteRoot(options.Root),
+		Bands:       options.coverageBands(),
+		GeneratedAt: time.Now().Format("2006-01-02 15:04:05"),
+		Files:       make([]FileReport, 0, len(files)),
+	}
+
+	for _, fileReport := range files {
+		if reason := filter.exclusionReason(fileReport); reason != "" {
+			report.Excluded = append(report.Excluded, ExcludedFile{
+				Name:         fileReport.Name,
+				RelativePath: filepath.ToSlash(fileReport.RelativeSourcePath),
+				Reason:       reason,
+			})
+			continue
+		}
+		report.Files = append(report.Files, fileReport)
+	}
 
+	uniqueAnchors(report.Files)
+
+	totalCovered := 0
+	totalStmts := 0
+
+	for _, fileReport := range report.Files {
 		totalCovered += fileReport.CoveredStmts
 		totalStmts += fileReport.TotalStmts
+		report.IgnoredStmts += fileReport.IgnoredStmts
 		if fileReport.Missing {
 			report.MissingFiles++
 		}
-		report.Files = append(report.Files, fileReport)
+		if fileReport.Untested {
+			report.UntestedFiles++
+		}
 	}
 
 	report.CoveredStmts = totalCovered
@@ -96,18 +301,46 @@ func Generate(profilePath, root, title string) (Report, error) {
 	report.TotalFiles = len(report.Files)
 	totalPercent := percent(totalCovered, totalStmts)
 	report.TotalCoveragePercent = formatPercent(totalPercent)
-	report.TotalCoverageClass = coverageClass(totalPercent)
-	report.Tree = buildTree(report.Files)
+	report.TotalCoverageClass = coverageClass(report.Bands, totalPercent)
+	report.Tree = buildTree(report.Files, report.Bands)
+	report.LeastCovered = leastCoveredFunctions(report.Files, leastCoveredLimit)
 
 	return report, nil
 }
 
-func buildFileReport(profile *cover.Profile, resolver *fileResolver) (FileReport, error) {
+func buildFileReport(profile *cover.Profile, resolver *fileResolver, bands []Band) (FileReport, error) {
 	fileName := profile.FileName
+	sourcePath, relativePath := resolver.resolve(fileName)
+	content, readErr := os.ReadFile(sourcePath)
+
+	var ignored []bool
+	var lines []string
+	if readErr == nil {
+		ignored = ignoredLines(sourcePath, content)
+		lines = strings.Split(string(content), "\n")
+	}
+
 	totalStmts := 0
 	coveredStmts := 0
+	ignoredStmts := 0
 
+	blocks := make([]BlockCoverage, 0, len(profile.Blocks))
 	for _, block := range profile.Blocks {
+		blockCoverage := BlockCoverage{
+			StartLine: block.StartLine,
+			StartCol:  block.StartCol,
+			EndLine:   block.EndLine,
+			EndCol:    block.EndCol,
+			NumStmt:   block.NumStmt,
+			Hits:      block.Count,
+			Ignored:   isBlockIgnored(ignored, lines, block),
+		}
+		blocks = append(blocks, blockCoverage)
+
+		if blockCoverage.Ignored {
+			ignoredStmts += block.NumStmt
+			continue
+		}
 		totalStmts += block.NumStmt
 		if block.Count > 0 {
 			coveredStmts += block.NumStmt
@@ -116,28 +349,39 @@ func buildFileReport(profile *cover.Profile, resolver *fileResolver) (FileReport
 
 	coveragePercent := percent(coveredStmts, totalStmts)
 	report := FileReport{
-		Name:            fileName,
-		CoveredStmts:    coveredStmts,
-		TotalStmts:      totalStmts,
-		CoveragePercent: formatPercent(coveragePercent),
-		CoverageClass:   coverageClass(coveragePercent),
-		Anchor:          sanitizeAnchor(fileName),
+		Name:               fileName,
+		CoveredStmts:       coveredStmts,
+		TotalStmts:         totalStmts,
+		IgnoredStmts:       ignoredStmts,
+		CoveragePercent:    formatPercent(coveragePercent),
+		CoverageClass:      coverageClass(bands, coveragePercent),
+		Anchor:             sanitizeAnchor(fileName),
+		Blocks:             blocks,
+		SourcePath:         absolutePath(sourcePath),
+		RelativeSourcePath: relativePath,
 	}
 
-	sourcePath, relativePath := resolver.resolve(fileName)
-	report.RelativeSourcePath = relativePath
-
-	content, err := os.ReadFile(sourcePath)
-	if err != nil {
+	if readErr != nil {
 		report.Missing = true
 		report.MissingDescription = fmt.Sprintf("source not found at %s", sourcePath)
 		return report, nil
 	}
 
-	lines := strings.Split(string(content), "\n")
+	report.Generated = isGenerated(content)
+	report.Functions = buildFunctions(sourcePath, content, blocks, bands)
+
 	lineStates := make([]lineState, len(lines))
 
-	for _, block := range profile.Blocks {
+	for _, block := range blocks {
+		if block.Ignored {
+			for line := block.StartLine; line <= block.EndLine && line <= len(lines); line++ {
+				if line >= 1 {
+					lineStates[line-1].ignored = true
+				}
+			}
+			continue
+		}
+
 		start := block.StartLine
 		end := block.EndLine
 		if start < 1 {
@@ -149,6 +393,13 @@ func buildFileReport(profile *cover.Profile, resolver *fileResolver) (FileReport
 		for line := start; line <= end; line++ {
 			state := &lineStates[line-1]
 			state.hasStmt = true
+			state.sumHits += block.Hits
+			if block.Hits > state.maxHits {
+				state.maxHits = block.Hits
+			}
+			if block.Hits > report.MaxHits {
+				report.MaxHits = block.Hits
+			}
 
 			lineText := lines[line-1]
 			maxCol := len(lineText) + 1
@@ -173,14 +424,14 @@ func buildFileReport(profile *cover.Profile, resolver *fileResolver) (FileReport
 				endCol = maxCol
 			}
 
-			if block.Count > 0 {
+			if block.Hits > 0 {
 				state.covered = true
 			} else {
 				state.missed = true
 				if endCol > startCol {
-					state.missedRanges = append(state.missedRanges, lineRange{
-						start: startCol,
-						end:   endCol,
+					state.missedRanges = append(state.missedRanges, ColumnRange{
+						Start: startCol,
+						End:   endCol,
 					})
 				}
 			}
@@ -199,19 +450,23 @@ func buildFileReport(profile *cover.Profile, resolver *fileResolver) (FileReport
 			} else if state.missed {
 				className = "missed"
 			}
+		} else if state.ignored {
+			className = "ignored"
 		}
 
-		partialRanges := ""
+		var mergedRanges []ColumnRange
 		if state.covered && state.missed {
-			mergedRanges := mergeRanges(state.missedRanges)
-			partialRanges = formatRanges(mergedRanges)
+			mergedRanges = mergeRanges(state.missedRanges)
 		}
 
 		report.Lines = append(report.Lines, LineCoverage{
-			Number: index + 1,
-			Code:   raw,
-			Class:  className,
-			Ranges: partialRanges,
+			Number:  index + 1,
+			Code:    raw,
+			Class:   className,
+			Missed:  mergedRanges,
+			MaxHits: state.maxHits,
+			SumHits: state.sumHits,
+			Heat:    heatLevel(state.maxHits, report.MaxHits),
 		})
 	}
 
@@ -227,7 +482,7 @@ type treeEntry struct {
 	totalStmts   int
 }
 
-func buildTree(files []FileReport) []TreeNode {
+func buildTree(files []FileReport, bands []Band) []TreeNode {
 	root := &treeEntry{children: map[string]*treeEntry{}}
 
 	for index := range files {
@@ -267,7 +522,7 @@ func buildTree(files []FileReport) []TreeNode {
 	}
 
 	computeTreeCoverage(root)
-	return buildTreeNodes(root)
+	return buildTreeNodes(root, bands)
 }
 
 func computeTreeCoverage(entry *treeEntry) (int, int) {
@@ -291,7 +546,7 @@ func computeTreeCoverage(entry *treeEntry) (int, int) {
 	return covered, total
 }
 
-func buildTreeNodes(entry *treeEntry) []TreeNode {
+func buildTreeNodes(entry *treeEntry, bands []Band) []TreeNode {
 	directories := make([]TreeNode, 0)
 	files := make([]TreeNode, 0)
 	keys := make([]string, 0, len(entry.children))
@@ -314,7 +569,10 @@ func buildTreeNodes(entry *treeEntry) []TreeNode {
 				Anchor:          child.file.Anchor,
 				CoveragePercent: child.file.CoveragePercent,
 				CoverageClass:   child.file.CoverageClass,
+				CoveredStmts:    child.file.CoveredStmts,
+				TotalStmts:      child.file.TotalStmts,
 				IsDir:           false,
+				Untested:        child.file.Untested,
 			})
 			continue
 		}
@@ -326,9 +584,9 @@ func buildTreeNodes(entry *treeEntry) []TreeNode {
 			CoveredStmts:    child.coveredStmts,
 			TotalStmts:      child.totalStmts,
 			CoveragePercent: formatPercent(coveragePercent),
-			CoverageClass:   coverageClass(coveragePercent),
+			CoverageClass:   coverageClass(bands, coveragePercent),
 			IsDir:           true,
-			Children:        buildTreeNodes(child),
+			Children:        buildTreeNodes(child, bands),
 		})
 	}
 
@@ -337,33 +595,37 @@ func buildTreeNodes(entry *treeEntry) []TreeNode {
 
 type lineState struct {
 	hasStmt      bool
+	ignored      bool
+	maxHits      int
+	sumHits      int
 	covered      bool
 	missed       bool
-	missedRanges []lineRange
+	missedRanges []ColumnRange
 }
 
-type lineRange struct {
-	start int
-	end   int
+// ColumnRange is a half-open range of 1-based byte columns on a line.
+type ColumnRange struct {
+	Start int
+	End   int
 }
 
-func mergeRanges(ranges []lineRange) []lineRange {
+func mergeRanges(ranges []ColumnRange) []ColumnRange {
 	if len(ranges) == 0 {
 		return nil
 	}
 	sort.Slice(ranges, func(i, j int) bool {
-		if ranges[i].start == ranges[j].start {
-			return ranges[i].end < ranges[j].end
+		if ranges[i].Start == ranges[j].Start {
+			return ranges[i].End < ranges[j].End
 		}
-		return ranges[i].start < ranges[j].start
+		return ranges[i].Start < ranges[j].Start
 	})
 
-	merged := make([]lineRange, 0, len(ranges))
+	merged := make([]ColumnRange, 0, len(ranges))
 	current := ranges[0]
 	for _, item := range ranges[1:] {
-		if item.start <= current.end {
-			if item.end > current.end {
-				current.end = item.end
+		if item.Start <= current.End {
+			if item.End > current.End {
+				current.End = item.End
 			}
 			continue
 		}
@@ -374,15 +636,22 @@ func mergeRanges(ranges []lineRange) []lineRange {
 	return merged
 }
 
-func formatRanges(ranges []lineRange) string {
-	if len(ranges) == 0 {
-		return ""
+const heatLevels = 5
+
+// heatLevel maps a hit count onto 1..heatLevels on a logarithmic scale
+// relative to the hottest line of the file. Lines that never ran get 0.
+func heatLevel(hits, maxHits int) int {
+	if hits <= 0 || maxHits <= 0 {
+		return 0
+	}
+	level := int(math.Ceil(heatLevels * math.Log1p(float64(hits)) / math.Log1p(float64(maxHits))))
+	if level < 1 {
+		return 1
 	}
-	parts := make([]string, 0, len(ranges))
-	for _, item := range ranges {
-		parts = append(parts, fmt.Sprintf("%d-%d", item.start, item.end))
+	if level > heatLevels {
+		return heatLevels
 	}
-	return strings.Join(parts, ",")
+	return level
 }
 
 func percent(covered, total int) float64 {
@@ -396,21 +665,23 @@ func formatPercent(value float64) string {
 	return fmt.Sprintf("%.1f%%", value)
 }
 
-func coverageClass(value float64) string {
-	switch {
-	case value >= 90:
-		return "high"
-	case value >= 75:
-		return "medium"
-	case value > 0:
-		return "low"
-	default:
-		return "none"
+var anchorPattern = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// uniqueAnchors gives files whose names sanitize to the same anchor, such as
+// a/b-c.go and a/b/c.go, a numeric suffix, so that every file has a section
+// and site page of its own.
+func uniqueAnchors(files []FileReport) {
+	used := make(map[string]bool, len(files))
+	for index := range files {
+		anchor := files[index].Anchor
+		for suffix := 2; used[anchor]; suffix++ {
+			anchor = fmt.Sprintf("%s-%d", files[index].Anchor, suffix)
+		}
+		used[anchor] = true
+		files[index].Anchor = anchor
 	}
 }
 
-var anchorPattern = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
-
 func sanitizeAnchor(value string) string {
 	sanitized := anchorPattern.ReplaceAllString(value, "-")
 	sanitized = strings.Trim(sanitized, "-")
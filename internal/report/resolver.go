@@ -10,24 +10,115 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
-
-	"golang.org/x/tools/cover"
 )
 
 type fileResolver struct {
-	root string
-	pkgs map[string]*goPackage
+	root     string
+	pkgs     map[string]*goPackage
+	mappings []PathMapping
+	// modulePath and moduleDir belong to the module containing the root,
+	// to place files of packages that cannot be listed, such as deleted ones.
+	modulePath string
+	moduleDir  string
+}
+
+// PathMapping rewrites coverage file names that are From or lie in the
+// directory From to start with To before they are resolved, e.g. to map the
+// checkout directory of a CI machine to the local one. A relative To is relative to the root.
+type PathMapping struct {
+	From string
+	To   string
+}
+
+// ParsePathMapping parses a "from=to" mapping.
+func ParsePathMapping(value string) (PathMapping, error) {
+	from, to, found := strings.Cut(value, "=")
+	if !found || from == "" || to == "" {
+		return PathMapping{}, fmt.Errorf("invalid path mapping %q: expected from=to", value)
+	}
+	return PathMapping{From: from, To: to}, nil
 }
 
 type goPackage struct {
 	ImportPath string
 	Dir        string
+	GoFiles    []string
+	CgoFiles   []string
 	Error      *struct {
 		Err string
 	}
 }
 
-func newFileResolver(root string, profiles []*cover.Profile) (*fileResolver, error) {
+func newFileResolver(root string, fileNames []string, mappings []PathMapping) (*fileResolver, error) {
+	resolvedRoot := absoluteRoot(root)
+
+	unmapped := make([]string, 0, len(fileNames))
+	for _, fileName := range fileNames {
+		if _, ok := mapPath(mappings, fileName); !ok {
+			unmapped = append(unmapped, fileName)
+		}
+	}
+
+	pkgs, err := findPackages(resolvedRoot, unmapped)
+	if err != nil {
+		return nil, err
+	}
+
+	modulePath, moduleDir := findModule(resolvedRoot)
+	return &fileResolver{
+		root:       resolvedRoot,
+		pkgs:       pkgs,
+		mappings:   mappings,
+		modulePath: modulePath,
+		moduleDir:  moduleDir,
+	}, nil
+}
+
+// findModule returns the module path and directory of the go.mod file in dir
+// or its closest parent, or empty strings when there is none.
+func findModule(dir string) (string, string) {
+	current := dir
+	for {
+		content, err := os.ReadFile(filepath.Join(current, "go.mod"))
+		if err == nil {
+			for _, line := range strings.Split(string(content), "\n") {
+				fields := strings.Fields(line)
+				if len(fields) >= 2 && fields[0] == "module" {
+					return strings.Trim(fields[1], "\"`"), current
+				}
+			}
+			return "", ""
+		}
+
+		parent := filepath.Dir(current)
+		if parent == current {
+			return "", ""
+		}
+		current = parent
+	}
+}
+
+// mapPath applies the first mapping whose From is fileName or a directory
+// containing it, so that "/src/app" maps "/src/app/x.go" but not
+// "/src/application/x.go".
+func mapPath(mappings []PathMapping, fileName string) (string, bool) {
+	for _, mapping := range mappings {
+		rest, found := strings.CutPrefix(fileName, mapping.From)
+		if !found {
+			continue
+		}
+		if mapping.From == "" || rest == "" || isSeparator(rest[0]) || isSeparator(mapping.From[len(mapping.From)-1]) {
+			return mapping.To + rest, true
+		}
+	}
+	return "", false
+}
+
+func isSeparator(char byte) bool {
+	return char == '/' || char == '\\'
+}
+
+func absoluteRoot(root string) string {
 	resolvedRoot := root
 	if resolvedRoot == "" {
 		resolvedRoot = "."
@@ -35,27 +126,29 @@ func newFileResolver(root string, profiles []*cover.Profile) (*fileResolver, err
 	if absRoot, err := filepath.Abs(resolvedRoot); err == nil {
 		resolvedRoot = absRoot
 	}
+	return resolvedRoot
+}
 
-	pkgs, err := findPackages(resolvedRoot, profiles)
-	if err != nil {
-		return nil, err
+// absolutePath returns path made absolute, or path itself when the working
+// directory is unknown.
+func absolutePath(path string) string {
+	if absolute, err := filepath.Abs(path); err == nil {
+		return absolute
 	}
-
-	return &fileResolver{
-		root: resolvedRoot,
-		pkgs: pkgs,
-	}, nil
+	return path
 }
 
 func (resolver *fileResolver) resolve(fileName string) (string, string) {
-	if filepath.IsAbs(fileName) {
-		relative := fileName
-		if resolver.root != "" {
-			if rel, err := filepath.Rel(resolver.root, fileName); err == nil && !strings.HasPrefix(rel, "..") {
-				relative = rel
-			}
+	if mapped, ok := mapPath(resolver.mappings, fileName); ok {
+		sourcePath := filepath.FromSlash(mapped)
+		if !filepath.IsAbs(sourcePath) {
+			sourcePath = filepath.Join(resolver.root, sourcePath)
 		}
-		return fileName, relative
+		return sourcePath, resolver.relative(sourcePath)
+	}
+
+	if filepath.IsAbs(fileName) {
+		return fileName, resolver.relative(fileName)
 	}
 
 	if strings.HasPrefix(fileName, ".") {
@@ -70,10 +163,26 @@ func (resolver *fileResolver) resolve(fileName string) (string, string) {
 		return resolved, relative
 	}
 
+	if resolver.modulePath != "" && strings.HasPrefix(fileName, resolver.modulePath+"/") {
+		sourcePath := filepath.Join(resolver.moduleDir, filepath.FromSlash(strings.TrimPrefix(fileName, resolver.modulePath+"/")))
+		return sourcePath, resolver.relative(sourcePath)
+	}
+
 	relative := filepath.FromSlash(fileName)
 	return filepath.Join(resolver.root, relative), relative
 }
 
+// relative returns sourcePath relative to the root, or sourcePath itself when
+// it lies outside the root.
+func (resolver *fileResolver) relative(sourcePath string) string {
+	if resolver.root != "" {
+		if rel, err := filepath.Rel(resolver.root, sourcePath); err == nil && !strings.HasPrefix(rel, "..") {
+			return rel
+		}
+	}
+	return sourcePath
+}
+
 func (resolver *fileResolver) resolveFromPackages(fileName string) (string, string) {
 	pkg := resolver.pkgs[path.Dir(fileName)]
 	if pkg == nil || pkg.Dir == "" || pkg.Error != nil {
@@ -93,12 +202,11 @@ func (resolver *fileResolver) resolveFromPackages(fileName string) (string, stri
 	return candidate, relative
 }
 
-func findPackages(root string, profiles []*cover.Profile) (map[string]*goPackage, error) {
+func findPackages(root string, fileNames []string) (map[string]*goPackage, error) {
 	pkgs := make(map[string]*goPackage)
 	list := make([]string, 0)
 
-	for _, profile := range profiles {
-		fileName := profile.FileName
+	for _, fileName := range fileNames {
 		if strings.HasPrefix(fileName, ".") || filepath.IsAbs(fileName) {
 			continue
 		}
@@ -115,19 +223,24 @@ func findPackages(root string, profiles []*cover.Profile) (map[string]*goPackage
 		return pkgs, nil
 	}
 
-	cmd := exec.Command("go", append([]string{"list", "-e", "-json"}, list...)...)
-	cmd.Dir = root
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
-	stdout, err := cmd.Output()
+	listed, err := listPackages(root, list...)
 	if err != nil {
-		message := strings.TrimSpace(stderr.String())
-		if message != "" {
-			return nil, fmt.Errorf("cannot run go list: %w: %s", err, message)
-		}
-		return nil, fmt.Errorf("cannot run go list: %w", err)
+		return nil, err
+	}
+	for _, pkg := range listed {
+		pkgs[pkg.ImportPath] = pkg
 	}
 
+	return pkgs, nil
+}
+
+func listPackages(root string, patterns ...string) ([]*goPackage, error) {
+	stdout, err := runGo(root, append([]string{"list", "-e", "-json"}, patterns...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	pkgs := make([]*goPackage, 0)
 	decoder := json.NewDecoder(bytes.NewReader(stdout))
 	for {
 		var pkg goPackage
@@ -137,12 +250,34 @@ func findPackages(root string, profiles []*cover.Profile) (map[string]*goPackage
 			}
 			return nil, fmt.Errorf("decoding go list json: %w", err)
 		}
-		pkgs[pkg.ImportPath] = &pkg
+		pkgs = append(pkgs, &pkg)
 	}
 
 	return pkgs, nil
 }
 
+func runGo(dir string, args ...string) ([]byte, error) {
+	return runCommand(dir, "go", args...)
+}
+
+func runCommand(dir, name string, args ...string) ([]byte, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.Output()
+	if err != nil {
+		command := name + " " + args[0]
+		message := strings.TrimSpace(stderr.String())
+		if message != "" {
+			return nil, fmt.Errorf("cannot run %s: %w: %s", command, err, message)
+		}
+		return nil, fmt.Errorf("cannot run %s: %w", command, err)
+	}
+
+	return stdout, nil
+}
+
 func fileExists(path string) bool {
 	info, err := os.Stat(path)
 	return err == nil && !info.IsDir()
@@ -0,0 +1,100 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveModulePaths(t *testing.T) {
+	module := t.TempDir()
+	if err := os.WriteFile(filepath.Join(module, "go.mod"), []byte("module example.com/m\n\ngo 1.20\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(module, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	noModule := t.TempDir()
+
+	// None of the packages exist, so go list cannot place them.
+	tests := []struct {
+		name         string
+		root         string
+		fileName     string
+		wantSource   string
+		wantRelative string
+	}{
+		{
+			name:         "module path under the root",
+			root:         module,
+			fileName:     "example.com/m/gone/gone.go",
+			wantSource:   filepath.Join(module, "gone", "gone.go"),
+			wantRelative: filepath.Join("gone", "gone.go"),
+		},
+		{
+			name:         "module path outside the root",
+			root:         filepath.Join(module, "sub"),
+			fileName:     "example.com/m/gone/gone.go",
+			wantSource:   filepath.Join(module, "gone", "gone.go"),
+			wantRelative: filepath.Join(module, "gone", "gone.go"),
+		},
+		{
+			name:         "other module",
+			root:         module,
+			fileName:     "example.com/other/gone.go",
+			wantSource:   filepath.Join(module, "example.com", "other", "gone.go"),
+			wantRelative: filepath.Join("example.com", "other", "gone.go"),
+		},
+		{
+			name:         "no go.mod",
+			root:         noModule,
+			fileName:     "example.com/m/gone/gone.go",
+			wantSource:   filepath.Join(noModule, "example.com", "m", "gone", "gone.go"),
+			wantRelative: filepath.Join("example.com", "m", "gone", "gone.go"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resolver, err := newFileResolver(test.root, []string{test.fileName}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			sourcePath, relative := resolver.resolve(test.fileName)
+			if sourcePath != test.wantSource || relative != test.wantRelative {
+				t.Errorf("resolve(%q) = %q, %q, want %q, %q", test.fileName, sourcePath, relative, test.wantSource, test.wantRelative)
+			}
+		})
+	}
+}
+
+func TestMapPath(t *testing.T) {
+	mappings := []PathMapping{
+		{From: "/src/app", To: "/local/app"},
+		{From: "/ci/", To: "/work/"},
+		{From: `C:\build`, To: "/win"},
+	}
+
+	tests := []struct {
+		fileName  string
+		want      string
+		wantFound bool
+	}{
+		{fileName: "/src/app/x.go", want: "/local/app/x.go", wantFound: true},
+		{fileName: "/src/app", want: "/local/app", wantFound: true},
+		{fileName: "/src/application/x.go"},
+		{fileName: "/src/app.go"},
+		{fileName: "/ci/x.go", want: "/work/x.go", wantFound: true},
+		{fileName: "/cix/x.go"},
+		{fileName: `C:\build\x.go`, want: `/win\x.go`, wantFound: true},
+		{fileName: `C:\builds\x.go`},
+	}
+
+	for _, test := range tests {
+		got, found := mapPath(mappings, test.fileName)
+		if got != test.want || found != test.wantFound {
+			t.Errorf("mapPath(%q) = %q, %v, want %q, %v", test.fileName, got, found, test.want, test.wantFound)
+		}
+	}
+}
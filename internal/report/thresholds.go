@@ -0,0 +1,112 @@
+package report
+
+import "fmt"
+
+// Thresholds are minimum coverage percentages. A zero value disables the
+// corresponding check.
+type Thresholds struct {
+	Total   float64
+	Package float64
+	File    float64
+}
+
+// Enabled reports whether any threshold is set.
+func (thresholds Thresholds) Enabled() bool {
+	return thresholds.Total > 0 || thresholds.Package > 0 || thresholds.File > 0
+}
+
+// Threshold scopes reported in ThresholdViolation.Scope.
+const (
+	ScopeTotal   = "total"
+	ScopePackage = "package"
+	ScopeFile    = "file"
+)
+
+// ThresholdViolation is an entry whose coverage is below its minimum.
+type ThresholdViolation struct {
+	Scope   string
+	Name    string
+	Percent float64
+	Minimum float64
+}
+
+func (violation ThresholdViolation) String() string {
+	return fmt.Sprintf("%s %s: coverage %s is below minimum %s", violation.Scope, violation.Name, formatPercent(violation.Percent), formatPercent(violation.Minimum))
+}
+
+// CheckThresholds compares the total, every package directory and every file
+// against thresholds. Failing entries are marked with BelowThreshold and
+// returned in report order.
+func CheckThresholds(report *Report, thresholds Thresholds) []ThresholdViolation {
+	report.Thresholds = thresholds
+	violations := make([]ThresholdViolation, 0)
+
+	totalPercent := percent(report.CoveredStmts, report.TotalStmts)
+	if belowThreshold(totalPercent, thresholds.Total) {
+		report.BelowThreshold = true
+		violations = append(violations, ThresholdViolation{
+			Scope:   ScopeTotal,
+			Name:    report.Title,
+			Percent: totalPercent,
+			Minimum: thresholds.Total,
+		})
+	}
+
+	violations = append(violations, checkTreeThresholds(report.Tree, thresholds)...)
+
+	for index := range report.Files {
+		file := &report.Files[index]
+		file.BelowThreshold = belowThreshold(percent(file.CoveredStmts, file.TotalStmts), thresholds.File)
+	}
+
+	return violations
+}
+
+func checkTreeThresholds(nodes []TreeNode, thresholds Thresholds) []ThresholdViolation {
+	violations := make([]ThresholdViolation, 0)
+	for index := range nodes {
+		node := &nodes[index]
+		nodePercent := percent(node.CoveredStmts, node.TotalStmts)
+		scope, minimum := ScopeFile, thresholds.File
+		if node.IsDir {
+			scope, minimum = ScopePackage, 0
+			if hasFileChild(node) {
+				minimum = thresholds.Package
+			}
+		}
+
+		node.BelowThreshold = belowThreshold(nodePercent, minimum)
+		if node.BelowThreshold {
+			violations = append(violations, ThresholdViolation{
+				Scope:   scope,
+				Name:    node.Path,
+				Percent: nodePercent,
+				Minimum: minimum,
+			})
+		}
+		if node.IsDir {
+			violations = append(violations, checkTreeThresholds(node.Children, thresholds)...)
+		}
+	}
+	return violations
+}
+
+// hasFileChild reports whether a directory holds source files itself rather
+// than only nested directories, which is what makes it a Go package.
+func hasFileChild(node *TreeNode) bool {
+	for _, child := range node.Children {
+		if !child.IsDir {
+			return true
+		}
+	}
+	return false
+}
+
+// belowThreshold compares at the precision percentages are displayed with,
+// so an entry shown as "80.0%" never fails a minimum of 80.
+func belowThreshold(value, minimum float64) bool {
+	if minimum <= 0 {
+		return false
+	}
+	return RoundPercent(value) < minimum
+}
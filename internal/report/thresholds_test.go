@@ -0,0 +1,86 @@
+package report
+
+import (
+	"reflect"
+	"testing"
+
+	"golang.org/x/tools/cover"
+)
+
+func TestCheckThresholds(t *testing.T) {
+	// a/x.go 3/4 (75%), a/y.go 1/1, b/z.go 2/3 (66.7%): package a 4/5 (80%),
+	// package b 2/3 and a total of 6/8 (75%).
+	profiles := []*cover.Profile{
+		testProfile("a/x.go", "set", testBlock(1, 1, 1, 2, 3, 1), testBlock(2, 1, 2, 2, 1, 0)),
+		testProfile("a/y.go", "set", testBlock(1, 1, 1, 2, 1, 1)),
+		testProfile("b/z.go", "set", testBlock(1, 1, 1, 2, 2, 1), testBlock(2, 1, 2, 2, 1, 0)),
+	}
+
+	tests := []struct {
+		name           string
+		thresholds     Thresholds
+		want           []string
+		wantBelowFiles []string
+	}{
+		{
+			name:       "disabled",
+			thresholds: Thresholds{},
+			want:       []string{},
+		},
+		{
+			name:       "at the minimums",
+			thresholds: Thresholds{Total: 75, Package: 66.7, File: 66.7},
+			want:       []string{},
+		},
+		{
+			name:       "total below",
+			thresholds: Thresholds{Total: 75.1},
+			want:       []string{"total Report: coverage 75.0% is below minimum 75.1%"},
+		},
+		{
+			name:       "package below",
+			thresholds: Thresholds{Package: 80},
+			want:       []string{"package b: coverage 66.7% is below minimum 80.0%"},
+		},
+		{
+			name:       "file below",
+			thresholds: Thresholds{File: 75.1},
+			want: []string{
+				"file a/x.go: coverage 75.0% is below minimum 75.1%",
+				"file b/z.go: coverage 66.7% is below minimum 75.1%",
+			},
+			wantBelowFiles: []string{"a/x.go", "b/z.go"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reportData, err := GenerateFromProfiles(profiles, Options{Root: t.TempDir(), Title: "Report"})
+			if err != nil {
+				t.Fatalf("GenerateFromProfiles() error = %v", err)
+			}
+
+			violations := CheckThresholds(&reportData, test.thresholds)
+			got := make([]string, 0, len(violations))
+			for _, violation := range violations {
+				got = append(got, violation.String())
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("CheckThresholds() = %q, want %q", got, test.want)
+			}
+
+			if reportData.BelowThreshold != (test.thresholds.Total > 75) {
+				t.Errorf("BelowThreshold = %v", reportData.BelowThreshold)
+			}
+			var belowFiles []string
+			for _, file := range reportData.Files {
+				if file.BelowThreshold {
+					belowFiles = append(belowFiles, file.Name)
+				}
+			}
+			if !reflect.DeepEqual(belowFiles, test.wantBelowFiles) {
+				t.Errorf("files below threshold = %v, want %v", belowFiles, test.wantBelowFiles)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package report
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"sort"
+
+	"golang.org/x/tools/cover"
+)
+
+// DefaultUntestedPattern is the package pattern listed when looking for
+// packages without coverage data.
+const DefaultUntestedPattern = "./..."
+
+// findUntestedProfiles lists the packages matching pattern and synthesizes
+// fully uncovered profiles for the ones none of profiles resolves into. The
+// listed packages are added to resolver, so the synthesized profiles resolve
+// too.
+func findUntestedProfiles(resolver *fileResolver, pattern string, profiles []*cover.Profile, mode string) ([]*cover.Profile, error) {
+	if pattern == "" {
+		pattern = DefaultUntestedPattern
+	}
+	if mode == "" {
+		mode = "set"
+	}
+
+	covered := make(map[string]bool)
+	for _, profile := range profiles {
+		sourcePath, _ := resolver.resolve(profile.FileName)
+		covered[filepath.Dir(sourcePath)] = true
+	}
+
+	pkgs, err := listPackages(resolver.root, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	untested := make([]*cover.Profile, 0)
+	for _, pkg := range pkgs {
+		if pkg.Error != nil || pkg.Dir == "" || covered[filepath.Clean(pkg.Dir)] {
+			continue
+		}
+		resolver.pkgs[pkg.ImportPath] = pkg
+
+		fileNames := append(append([]string{}, pkg.GoFiles...), pkg.CgoFiles...)
+		sort.Strings(fileNames)
+		for _, fileName := range fileNames {
+			blocks, err := statementBlocks(filepath.Join(pkg.Dir, fileName))
+			if err != nil {
+				return nil, err
+			}
+			untested = append(untested, &cover.Profile{
+				FileName: pkg.ImportPath + "/" + fileName,
+				Mode:     mode,
+				Blocks:   blocks,
+			})
+		}
+	}
+
+	return untested, nil
+}
+
+// statementBlocks returns one unexecuted block per statement inside function
+// bodies, approximating the blocks `go test -cover` would have produced.
+func statementBlocks(sourcePath string) ([]cover.ProfileBlock, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, sourcePath, nil, 0)
+	if err != nil {
+		return nil, fmt.Errorf("parse untested file: %w", err)
+	}
+
+	blocks := make([]cover.ProfileBlock, 0)
+	addStatements := func(statements []ast.Stmt) {
+		for _, statement := range statements {
+			switch statement.(type) {
+			case *ast.BlockStmt, *ast.CaseClause, *ast.CommClause:
+				// Nested blocks and the clauses of switch and select bodies
+				// are not statements; their contents are added on their own.
+				continue
+			}
+			start := fset.Position(statement.Pos())
+			end := fset.Position(statementHeaderEnd(statement))
+			blocks = append(blocks, cover.ProfileBlock{
+				StartLine: start.Line,
+				StartCol:  start.Column,
+				EndLine:   end.Line,
+				EndCol:    end.Column,
+				NumStmt:   1,
+			})
+		}
+	}
+
+	for _, decl := range file.Decls {
+		funcDecl, ok := decl.(*ast.FuncDecl)
+		if !ok || funcDecl.Body == nil {
+			continue
+		}
+		ast.Inspect(funcDecl.Body, func(node ast.Node) bool {
+			switch typed := node.(type) {
+			case *ast.BlockStmt:
+				addStatements(typed.List)
+			case *ast.CaseClause:
+				addStatements(typed.Body)
+			case *ast.CommClause:
+				addStatements(typed.Body)
+			case *ast.IfStmt:
+				if elseIf, ok := typed.Else.(*ast.IfStmt); ok {
+					addStatements([]ast.Stmt{elseIf})
+				}
+			}
+			return true
+		})
+	}
+
+	sort.Slice(blocks, func(i, j int) bool {
+		if blocks[i].StartLine != blocks[j].StartLine {
+			return blocks[i].StartLine < blocks[j].StartLine
+		}
+		return blocks[i].StartCol < blocks[j].StartCol
+	})
+
+	return blocks, nil
+}
+
+// statementHeaderEnd returns where the part of a statement that runs before
+// its nested body ends, so nested statements get blocks of their own.
+func statementHeaderEnd(statement ast.Stmt) token.Pos {
+	switch typed := statement.(type) {
+	case *ast.IfStmt:
+		return typed.Body.Lbrace + 1
+	case *ast.ForStmt:
+		return typed.Body.Lbrace + 1
+	case *ast.RangeStmt:
+		return typed.Body.Lbrace + 1
+	case *ast.SwitchStmt:
+		return typed.Body.Lbrace + 1
+	case *ast.TypeSwitchStmt:
+		return typed.Body.Lbrace + 1
+	case *ast.SelectStmt:
+		return typed.Body.Lbrace + 1
+	case *ast.LabeledStmt:
+		return statementHeaderEnd(typed.Stmt)
+	default:
+		return statement.End()
+	}
+}
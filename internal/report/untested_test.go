@@ -0,0 +1,142 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"golang.org/x/tools/cover"
+)
+
+func writeTestModule(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod":   "module example.com/mod\n\ngo 1.20\n",
+		"a/a.go":   "package a\n\nfunc A() int {\n\treturn 1\n}\n",
+		"b/b.go":   "package b\n\nfunc B() int {\n\treturn 2\n}\n",
+		"c/c.go":   "package c\n\nfunc C() int {\n\treturn 3\n}\n",
+		"c/doc.go": "// Package c has no statements here.\npackage c\n",
+	}
+	for name, content := range files {
+		filePath := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filePath, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestFindUntestedProfiles(t *testing.T) {
+	root := writeTestModule(t)
+
+	tests := []struct {
+		name     string
+		fileName func() string
+		mappings []PathMapping
+	}{
+		{name: "import path", fileName: func() string { return "example.com/mod/a/a.go" }},
+		{name: "relative path", fileName: func() string { return "./a/a.go" }},
+		{name: "absolute path", fileName: func() string { return filepath.Join(root, "a", "a.go") }},
+		{
+			name:     "mapped path",
+			fileName: func() string { return "/ci/checkout/a/a.go" },
+			mappings: []PathMapping{{From: "/ci/checkout/", To: root + "/"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			profiles := []*cover.Profile{testProfile(test.fileName(), "set", testBlock(3, 14, 5, 2, 1, 1))}
+			resolver, err := newFileResolver(root, []string{profiles[0].FileName}, test.mappings)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			untested, err := findUntestedProfiles(resolver, "", profiles, "set")
+			if err != nil {
+				t.Fatalf("findUntestedProfiles() error = %v", err)
+			}
+
+			got := make([]string, 0, len(untested))
+			for _, profile := range untested {
+				got = append(got, profile.FileName)
+				if sourcePath, _ := resolver.resolve(profile.FileName); !fileExists(sourcePath) {
+					t.Errorf("untested profile %s resolves to missing %s", profile.FileName, sourcePath)
+				}
+			}
+			sort.Strings(got)
+			want := []string{"example.com/mod/b/b.go", "example.com/mod/c/c.go", "example.com/mod/c/doc.go"}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("findUntestedProfiles() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestStatementBlocksSwitchAndSelect(t *testing.T) {
+	source := `package s
+
+func Switch(x int) int {
+	switch x {
+	case -1:
+		return 1
+	case 0, 1:
+		x++
+		fallthrough
+	default:
+		x--
+	}
+	return x
+}
+
+func TypeSwitch(v any) string {
+	switch v.(type) {
+	case int:
+		return "int"
+	}
+	return ""
+}
+
+func Select(a, b chan int) int {
+	select {
+	case v := <-a:
+		return v
+	case b <- 1:
+	default:
+		return 0
+	}
+	return -1
+}
+`
+	sourcePath := filepath.Join(t.TempDir(), "s.go")
+	if err := os.WriteFile(sourcePath, []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	blocks, err := statementBlocks(sourcePath)
+	if err != nil {
+		t.Fatalf("statementBlocks() error = %v", err)
+	}
+
+	// go test -cover counts 13 statements in the source, on these lines.
+	statements := 0
+	lines := make([]int, 0, len(blocks))
+	for _, block := range blocks {
+		statements += block.NumStmt
+		for line := block.StartLine; line <= block.EndLine; line++ {
+			lines = append(lines, line)
+		}
+	}
+	if statements != 13 {
+		t.Errorf("statements = %d, want 13", statements)
+	}
+	if want := []int{4, 6, 8, 9, 11, 13, 17, 19, 21, 25, 27, 30, 32}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("statement lines = %v, want %v", lines, want)
+	}
+}